@@ -0,0 +1,18 @@
+// Package analysis determines the effect of the loops of a brainfuck program
+// on the tape, for use by optimizers, linters and compilers. The linter and
+// the fast engine of package bf use the same analysis.
+package analysis
+
+import "github.com/sanderhahn/go-bf"
+
+// Unknown is the number of iterations that can't be determined
+const Unknown = bf.Unknown
+
+// Loop describes the effect of a single iteration of a loop, cells are
+// relative to the pointer at the start of the iteration
+type Loop = bf.Loop
+
+// Analyze returns the loops of the source in the order of their start
+func Analyze(source []byte) ([]*Loop, error) {
+	return bf.AnalyzeLoops(source)
+}
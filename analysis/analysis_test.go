@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/sanderhahn/go-bf"
+)
+
+func analyze(t *testing.T, source []byte) []*Loop {
+	loops, err := Analyze(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != bytes.Count(source, []byte("[")) {
+		t.Fatalf("%d loops for %d brackets", len(loops), bytes.Count(source, []byte("[")))
+	}
+	return loops
+}
+
+func TestAnalyze(t *testing.T) {
+	loops := analyze(t, []byte("++[>+++[>+<-]<-]\n,[->+<]+[>]+[--]"))
+	outer, inner, moved, scan, stuck := loops[0], loops[1], loops[2], loops[3], loops[4]
+	if outer.Depth != 0 || !outer.Balanced || !reflect.DeepEqual(outer.Writes, []int{0, 1, 2}) ||
+		!outer.Counted || outer.Iterations != 2 || outer.Deltas != nil {
+		t.Errorf("outer %+v", outer)
+	}
+	if inner.Depth != 1 || inner.Start != (bf.Position{Offset: 7, Line: 1, Column: 8}) ||
+		inner.Iterations != Unknown || !reflect.DeepEqual(inner.Deltas, map[int]int{0: -1, 1: 1}) {
+		t.Errorf("inner %+v", inner)
+	}
+	if moved.Start.Line != 2 || !moved.Counted || moved.Step != -1 || moved.Iterations != Unknown {
+		t.Errorf("moved %+v", moved)
+	}
+	if scan.Balanced || scan.Movement != 1 || scan.Counted || scan.Deltas != nil {
+		t.Errorf("scan %+v", scan)
+	}
+	if stuck.Step != -2 || stuck.Iterations != Unknown {
+		t.Errorf("stuck %+v", stuck)
+	}
+	if _, err := Analyze([]byte("[]]")); err != bf.ErrInvalidNesting {
+		t.Errorf("expected invalid nesting, got %v", err)
+	}
+}
+
+func TestLife(t *testing.T) {
+	source, err := ioutil.ReadFile("../examples/life.bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loops := analyze(t, source)
+	// +>>++++[<++++>-]<[<++++++>-]+[
+	first, second, main := loops[0], loops[1], loops[2]
+	if first.Start.Line != 4 || first.Start.Column != 15 || first.Iterations != 4 ||
+		!reflect.DeepEqual(first.Deltas, map[int]int{-1: 4, 0: -1}) {
+		t.Errorf("first %+v", first)
+	}
+	if second.Iterations != 16 || !reflect.DeepEqual(second.Reads, []int{-1, 0}) {
+		t.Errorf("second %+v", second)
+	}
+	if main.Tracked || main.Balanced || main.Iterations != Unknown {
+		t.Errorf("main %+v", main)
+	}
+	for _, loop := range loops[3:] {
+		if loop.Depth == 0 {
+			t.Errorf("loop at %s isn't nested in the main loop", loop.Start)
+		}
+	}
+}
+
+func TestMandelbrot(t *testing.T) {
+	source, err := ioutil.ReadFile("../examples/mandelbrot.bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loops := analyze(t, source)
+	// +++++++++++++[->++>>>+++++>++>+<<<<<<]
+	first := loops[0]
+	if first.Start.Line != 2 || first.Start.Column != 14 || first.Iterations != 13 ||
+		!reflect.DeepEqual(first.Deltas, map[int]int{0: -1, 1: 2, 4: 5, 5: 2, 6: 1}) {
+		t.Errorf("first %+v", first)
+	}
+	// [>>>>>>>>>] moves to the next cell that is zero
+	scan := loops[2]
+	if scan.Depth != 1 || scan.Balanced || scan.Movement != 9 || !reflect.DeepEqual(scan.Writes, []int{}) {
+		t.Errorf("scan %+v", scan)
+	}
+	balanced := 0
+	for _, loop := range loops {
+		if loop.Balanced {
+			balanced++
+			if loop.Movement != 0 || !loop.Tracked {
+				t.Errorf("balanced loop at %s moves %d", loop.Start, loop.Movement)
+			}
+		}
+	}
+	if balanced == 0 || balanced == len(loops) {
+		t.Errorf("%d of %d loops are balanced", balanced, len(loops))
+	}
+}
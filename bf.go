@@ -8,9 +8,17 @@ import (
 const memorySize = 1024
 const stackSize = 1024
 
-var errInvalidNesting = errors.New("Invalid loop nesting")
-var errMemory = errors.New("Memory below zero unsupported")
-var errExhaustedRuntime = errors.New("Runtime exhausted")
+// Errors of running programs
+var (
+	ErrInvalidNesting   = errors.New("Invalid loop nesting")
+	ErrMemory           = errors.New("Memory below zero unsupported")
+	ErrExhaustedRuntime = errors.New("Runtime exhausted")
+	ErrTape             = errors.New("Pointer outside of tape")
+	ErrDeadline         = errors.New("Deadline exceeded")
+	ErrUnsupported      = errors.New("Option unsupported by engine")
+	ErrDivisionByZero   = errors.New("Division by zero")
+	ErrThreads          = errors.New("Too many threads")
+)
 
 // Interpreter has the state for a single interpreter
 type Interpreter struct {
@@ -21,6 +29,27 @@ type Interpreter struct {
 	ip     int    // instruction pointer
 	code   []byte // code memory
 	stack  []int  // return stack
+	// InputSeparator reads the code up to the first `!` before running it
+	// and reads the input from the remainder of the code reader, it is
+	// unsupported by the extended types that use `!` as instruction
+	InputSeparator bool
+	separated      bool // the separator has been read
+	// Extension enables the instructions of an extended dialect
+	Extension Extension
+	storage   byte // storage register
+	// MaxThreads limits the number of brainfork threads, zero uses the default
+	MaxThreads     int
+	forked         bool         // fork requested by the current thread
+	threads        []*thread    // running brainfork threads
+	current        int          // index of the current thread
+	threadRuntimes []int        // instructions executed by each thread
+	procedures     map[byte]int // start of the pbrain procedures
+	calls          []int        // pbrain return stack
+	// Options of the tape, input and limits, cell widths other than 8 bits
+	// are unsupported
+	Options
+	instructions int // executed instructions
+	maxPtr       int // rightmost pointer position
 }
 
 func memory() []byte {
@@ -78,19 +107,83 @@ func writeByte(w io.Writer, b byte) error {
 	return nil
 }
 
+// increaseMemory handles the pointer moving beyond the rightmost used cell
 func (i *Interpreter) increaseMemory() error {
-	// increase memory on demand
 	if i.ptr >= len(i.memory) {
-		i.memory = append(i.memory, memory()...)
+		switch {
+		case i.Extension.sharedMemory():
+			i.memory = append(i.memory, memory()...)
+			i.code = i.memory
+		case i.Tape == TapeWrap:
+			i.ptr = 0
+		case i.Tape == TapeError:
+			return ErrTape
+		case i.TapeSize > 0 && len(i.memory) >= i.TapeSize:
+			return ErrTape
+		default:
+			// increase memory on demand
+			grow := memory()
+			if i.TapeSize > 0 && len(i.memory)+len(grow) > i.TapeSize {
+				grow = grow[:i.TapeSize-len(i.memory)]
+			}
+			i.memory = append(i.memory, grow...)
+		}
+	}
+	i.track()
+	return nil
+}
+
+// decreaseMemory handles the pointer moving left of the first cell
+func (i *Interpreter) decreaseMemory() error {
+	if i.Tape == TapeWrap && !i.Extension.sharedMemory() {
+		i.ptr = len(i.memory) - 1
+		i.track()
+		return nil
+	}
+	return ErrMemory
+}
+
+// track records the rightmost pointer position
+func (i *Interpreter) track() {
+	if i.ptr > i.maxPtr {
+		i.maxPtr = i.ptr
+	}
+}
+
+// prepareTape applies the options to the memory
+func (i *Interpreter) prepareTape() error {
+	if width, err := i.cellBytes(); err != nil || width != 1 {
+		return ErrUnsupported
+	}
+	if i.Extension.sharedMemory() {
+		return nil
 	}
+	size := len(i.memory)
+	if i.Tape != TapeGrow {
+		size = i.tapeSize()
+	} else if i.TapeSize > 0 && size > i.TapeSize {
+		size = i.TapeSize
+	}
+	if size > len(i.memory) {
+		i.memory = append(i.memory, make([]byte, size-len(i.memory))...)
+	}
+	i.memory = i.memory[:size]
 	return nil
 }
 
+// RunStats returns the statistics of the execution so far
+func (i *Interpreter) RunStats() RunStats {
+	return RunStats{Instructions: i.instructions, MaxTape: i.maxPtr + 1}
+}
+
 // instr reads and caches code instructions from the reader into code memory
 func (i *Interpreter) instr(r io.Reader) (code byte, err error) {
 	if i.ip < len(i.code) {
 		// previously read code
 		code = i.code[i.ip]
+	} else if i.separated {
+		// the remainder of the reader is input
+		err = io.EOF
 	} else {
 		// read new instruction
 		code, err = readByte(r)
@@ -126,41 +219,144 @@ func (i *Interpreter) pop() {
 
 // Interpret the instructions from the reader
 func (i *Interpreter) Interpret(r io.Reader) error {
-	_, err := i.interpret(r, true, -1)
+	runtime := -1
+	if i.MaxInstructions > 0 {
+		runtime = i.MaxInstructions
+	}
+	_, err := i.interpret(r, true, runtime, false)
 	return err
 }
 
+// readCode reads the code up to the end marker into code memory
+func (i *Interpreter) readCode(r io.Reader, end byte) (found bool, err error) {
+	for {
+		code, err := readByte(r)
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if code == end {
+			return true, nil
+		}
+		i.code = append(i.code, code)
+	}
+}
+
+// separate reads the code up to the first `!`, the remainder of the reader
+// is used as input
+func (i *Interpreter) separate(r io.Reader) error {
+	if _, err := i.readCode(r, '!'); err != nil {
+		return err
+	}
+	i.separated = true
+	i.r = r
+	return nil
+}
+
 // InterpretExtended interprets the instructions from the reader in a non strict fashion
-// (non matching brackets are ignored). Runtime of -1 means unrestricted.
+// (non matching brackets are ignored). Runtime of -1 means unrestricted, the
+// runtime also counts comments and the end of the program.
 func (i *Interpreter) InterpretExtended(r io.Reader, strict bool, runtime int) (int, error) {
-	return i.interpret(r, strict, runtime)
+	return i.interpret(r, strict, runtime, true)
 }
 
-func (i *Interpreter) interpret(r io.Reader, strict bool, runtime int) (int, error) {
+// interpret runs the program, comments and the end of the program only
+// count toward the runtime when countComments is set
+func (i *Interpreter) interpret(r io.Reader, strict bool, runtime int, countComments bool) (int, error) {
+	if err := i.prepareTape(); err != nil {
+		return runtime, err
+	}
+	if i.InputSeparator && i.Extension.extendedTypeI() {
+		return runtime, ErrUnsupported
+	}
+	if i.InputSeparator && !i.separated {
+		if err := i.separate(r); err != nil {
+			return runtime, err
+		}
+	}
+	if i.Extension.extendedTypeI() {
+		if err := i.prefetch(r); err != nil {
+			return runtime, err
+		}
+	}
+	if i.Extension == Brainfork {
+		i.startThreads()
+	}
+	limited := runtime >= 0
+	if !limited {
+		runtime = maxInt
+	}
+	start := runtime
+	runtime, skipped, err := i.run(r, strict, runtime, countComments)
+	i.instructions += start - runtime - skipped
+	if !limited {
+		runtime = -1
+	}
+	return runtime, err
+}
+
+// maxInt is the runtime of programs that run unrestricted
+const maxInt = int(^uint(0) >> 1)
+
+// run executes the instructions until the runtime is exhausted and returns
+// the remaining runtime and the number of comments and program ends that
+// were counted toward the runtime
+func (i *Interpreter) run(r io.Reader, strict bool, runtime int, countComments bool) (int, int, error) {
+	start, skipped := runtime, 0
+	deadline, check := !i.Deadline.IsZero(), deadlineInterval
+	brainfork := i.Extension == Brainfork
+	extended := instructionTables[i.Extension]
+loop:
 	for {
-		code, err := i.instr(r)
-		if runtime == 0 {
-			return runtime, errExhaustedRuntime
+		var code byte
+		var err error
+		if i.ip < len(i.code) {
+			// previously read code
+			code = i.code[i.ip]
+			i.ip++
+		} else {
+			code, err = i.instr(r)
 		}
-		if runtime > 0 {
-			runtime--
+		if runtime == 0 && (countComments || i.executes(code, err, extended)) {
+			return runtime, skipped, ErrExhaustedRuntime
 		}
+		runtime--
 		if err == io.EOF {
+			if countComments {
+				skipped++
+			} else {
+				runtime++
+			}
+			if brainfork {
+				running, err := i.exitThread(strict)
+				if err != nil {
+					return runtime, skipped, err
+				}
+				if running {
+					continue
+				}
+			}
 			break
 		}
 		if err != nil {
-			return runtime, err
+			return runtime, skipped, err
 		}
 		switch code {
 		case '>':
 			i.ptr++
-			if err := i.increaseMemory(); err != nil {
-				return runtime, err
+			if i.ptr > i.maxPtr {
+				if err := i.increaseMemory(); err != nil {
+					return runtime, skipped, err
+				}
 			}
 		case '<':
 			i.ptr--
 			if i.ptr < 0 {
-				return runtime, errMemory
+				if err := i.decreaseMemory(); err != nil {
+					return runtime, skipped, err
+				}
 			}
 		case '+':
 			i.memory[i.ptr]++
@@ -170,15 +366,22 @@ func (i *Interpreter) interpret(r io.Reader, strict bool, runtime int) (int, err
 			b := i.memory[i.ptr]
 			err := writeByte(i.w, b)
 			if err != nil {
-				return runtime, err
+				return runtime, skipped, err
 			}
 		case ',':
 			b, err := readByte(i.r)
 			if err == io.EOF {
-				// dbf2c.bf expects zero as EOF
-				b = 0
+				switch i.EOF {
+				case EOFUnchanged:
+					b = i.memory[i.ptr]
+				case EOFMinusOne:
+					b = 0xff
+				default:
+					// dbf2c.bf expects zero as EOF
+					b = 0
+				}
 			} else if err != nil {
-				return runtime, err
+				return runtime, skipped, err
 			}
 			i.memory[i.ptr] = b
 		case '[':
@@ -187,7 +390,7 @@ func (i *Interpreter) interpret(r io.Reader, strict bool, runtime int) (int, err
 					if err == io.EOF && !strict {
 						break
 					}
-					return runtime, err
+					return runtime, skipped, err
 				}
 			} else {
 				i.push()
@@ -195,21 +398,80 @@ func (i *Interpreter) interpret(r io.Reader, strict bool, runtime int) (int, err
 		case ']':
 			if len(i.stack) < 1 {
 				if strict {
-					return runtime, errInvalidNesting
+					return runtime, skipped, ErrInvalidNesting
 				}
-				continue
+				break
 			}
 			if i.condition() {
 				i.pop()
+				break
+			}
+			i.jump()
+			// the deadline is only checked when jumping back
+			if deadline && i.pastDeadline(start-runtime, &check) {
+				return runtime, skipped, ErrDeadline
+			}
+		case '@':
+			if i.Extension.extendedTypeI() {
+				// end of program
+				break loop
+			}
+			if countComments {
+				skipped++
 			} else {
-				i.jump()
+				runtime++
 			}
 		default:
-			// ignore comments
+			// extended instructions, otherwise comments
+			if !extended[code] {
+				if countComments {
+					skipped++
+				} else {
+					runtime++
+				}
+				break
+			}
+			if err := i.extended(code, r, strict); err != nil {
+				return runtime, skipped, err
+			}
+			// ? jumps to the pointer
+			if deadline && i.pastDeadline(start-runtime, &check) {
+				return runtime, skipped, ErrDeadline
+			}
+		}
+		if brainfork {
+			if err := i.schedule(); err != nil {
+				return runtime, skipped, err
+			}
 		}
 	}
 	if len(i.stack) > 0 && strict {
-		return runtime, errInvalidNesting
+		return runtime, skipped, ErrInvalidNesting
+	}
+	return runtime, skipped, nil
+}
+
+// executes reports if the code is an instruction that is counted when
+// comments are not
+func (i *Interpreter) executes(code byte, err error, extended *[256]bool) bool {
+	if err != nil {
+		return err != io.EOF
+	}
+	switch code {
+	case '>', '<', '+', '-', '.', ',', '[', ']':
+		return true
+	case '@':
+		return i.Extension.extendedTypeI()
+	}
+	return extended[code]
+}
+
+// pastDeadline checks the deadline once the executed instructions reach
+// the next check
+func (i *Interpreter) pastDeadline(executed int, check *int) bool {
+	if executed < *check {
+		return false
 	}
-	return runtime, nil
+	*check = executed + deadlineInterval
+	return i.expired()
 }
@@ -80,7 +80,7 @@ func TestRuntime(t *testing.T) {
 	out := &strings.Builder{}
 	i := NewInterpreter(out, nil)
 	_, err := i.InterpretExtended(code(`+++.`), true, 1)
-	if err != errExhaustedRuntime {
+	if err != ErrExhaustedRuntime {
 		t.Fail()
 	}
 	i = NewInterpreter(out, nil)
@@ -90,7 +90,7 @@ func TestRuntime(t *testing.T) {
 	}
 	i = NewInterpreter(out, nil)
 	_, err = i.InterpretExtended(code(`+[]`), true, 10)
-	if err != errExhaustedRuntime {
+	if err != ErrExhaustedRuntime {
 		t.Fail()
 	}
 }
@@ -488,3 +488,45 @@ j----------
 		t.Fatalf("%s != %s", out.String(), expected)
 	}
 }
+
+func TestInputSeparator(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{",.,.!ab", "ab"},
+		// cached loop code continues reading input after the separator
+		{",[.,]!hello", "hello"},
+		// the first ! ends the code, also in comments
+		{"+. wow! ,.", "\x01"},
+		{"!", ""},
+	}
+	for _, test := range tests {
+		out := &strings.Builder{}
+		i := NewInterpreter(out, strings.NewReader("unused"))
+		i.InputSeparator = true
+		if err := i.Interpret(code(test.program)); err != nil {
+			t.Fatalf("%q: %v", test.program, err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%q printed %q expected %q", test.program, out.String(), test.expected)
+		}
+	}
+
+	out := &strings.Builder{}
+	i := NewInterpreter(out, strings.NewReader("z"))
+	if err := i.Interpret(code(",.!a")); err != nil || out.String() != "z" {
+		t.Fatalf("without separator printed %q", out.String())
+	}
+	i = NewInterpreter(out, nil)
+	i.InputSeparator = true
+	if err := i.Interpret(code("[!]")); err == nil {
+		t.Fatal("expected unmatched loop")
+	}
+	i = NewInterpreter(out, nil)
+	i.InputSeparator = true
+	i.Extension = ExtendedTypeI
+	if err := i.Interpret(code("!@")); err != ErrUnsupported {
+		t.Fatalf("expected unsupported, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package bf
+
+const defaultMaxThreads = 1024
+
+// thread is the state of a brainfork thread, the memory is shared
+type thread struct {
+	id    int
+	ptr   int
+	ip    int
+	stack []int
+}
+
+// fork zeroes the current cell of the parent, the child is started after
+// the instruction completes
+func (i *Interpreter) fork() {
+	i.memory[i.ptr] = 0
+	i.forked = true
+}
+
+func (i *Interpreter) maxThreads() int {
+	if i.MaxThreads > 0 {
+		return i.MaxThreads
+	}
+	return defaultMaxThreads
+}
+
+// ThreadRuntimes returns the number of instructions that each thread
+// executed in the order the threads were started
+func (i *Interpreter) ThreadRuntimes() []int {
+	return i.threadRuntimes
+}
+
+// startThreads makes the current state the main thread
+func (i *Interpreter) startThreads() {
+	i.threads = []*thread{{ptr: i.ptr, ip: i.ip, stack: i.stack}}
+	i.current = 0
+	i.threadRuntimes = []int{0}
+}
+
+// save the registers of the current thread
+func (i *Interpreter) save() *thread {
+	t := i.threads[i.current]
+	t.ptr, t.ip, t.stack = i.ptr, i.ip, i.stack
+	i.threadRuntimes[t.id]++
+	return t
+}
+
+// restore the registers of the current thread
+func (i *Interpreter) restore() {
+	t := i.threads[i.current]
+	i.ptr, i.ip, i.stack = t.ptr, t.ip, t.stack
+}
+
+// schedule switches to the next thread after every instruction, so that the
+// output is reproducible. The child of a fork is scheduled directly after
+// its parent and starts with the pointer moved one cell to the right and
+// that cell set to one.
+func (i *Interpreter) schedule() error {
+	if !i.forked && len(i.threads) == 1 {
+		// a single thread keeps running without switching
+		i.threadRuntimes[i.threads[0].id]++
+		return nil
+	}
+	t := i.save()
+	if i.forked {
+		i.forked = false
+		if len(i.threads) >= i.maxThreads() {
+			return ErrThreads
+		}
+		child := &thread{
+			id:    len(i.threadRuntimes),
+			ptr:   t.ptr + 1,
+			ip:    t.ip,
+			stack: append([]int{}, t.stack...),
+		}
+		i.ptr = child.ptr
+		if err := i.increaseMemory(); err != nil {
+			return err
+		}
+		// the pointer wraps around the end of the tape
+		child.ptr = i.ptr
+		i.memory[child.ptr] = 1
+		i.threadRuntimes = append(i.threadRuntimes, 0)
+		next := i.current + 1
+		i.threads = append(i.threads[:next], append([]*thread{child}, i.threads[next:]...)...)
+	}
+	i.current = (i.current + 1) % len(i.threads)
+	i.restore()
+	return nil
+}
+
+// exitThread ends the current thread at the end of the program and
+// switches to the next thread while threads are running
+func (i *Interpreter) exitThread(strict bool) (bool, error) {
+	t := i.save()
+	if len(t.stack) > 0 && strict {
+		return false, ErrInvalidNesting
+	}
+	i.threads = append(i.threads[:i.current], i.threads[i.current+1:]...)
+	if len(i.threads) == 0 {
+		return false, nil
+	}
+	i.current %= len(i.threads)
+	i.restore()
+	return true, nil
+}
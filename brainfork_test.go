@@ -0,0 +1,66 @@
+package bf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBrainfork(t *testing.T) {
+	out := &strings.Builder{}
+	i := NewInterpreter(out, nil)
+	i.Extension = Brainfork
+	// the parent prints one and the child two, interleaved per instruction
+	if err := i.Interpret(code("Y+.")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\x02\x01" {
+		t.Fatalf("printed %q", out.String())
+	}
+	if !reflect.DeepEqual(i.ThreadRuntimes(), []int{4, 3}) {
+		t.Fatalf("runtimes %v", i.ThreadRuntimes())
+	}
+
+	// only the child enters the loop
+	if out := extended(t, Brainfork, "Y[-<+++++++++++++++++++++++++++++++++++++++++++++++++.>]", ""); out != "1" {
+		t.Fatalf("printed %q", out)
+	}
+	// without the extension Y is a comment
+	if out := extended(t, NoExtension, "Y+.", ""); out != "\x01" {
+		t.Fatalf("printed %q", out)
+	}
+}
+
+func TestBrainforkLimits(t *testing.T) {
+	i := NewInterpreter(nil, nil)
+	i.Extension = Brainfork
+	i.MaxThreads = 8
+	if err := i.Interpret(code("+[Y+]")); err != ErrThreads {
+		t.Fatal(err)
+	}
+	i = NewInterpreter(nil, nil)
+	i.Extension = Brainfork
+	if _, err := i.InterpretExtended(code("+[Y+]"), true, 100); err != ErrExhaustedRuntime {
+		t.Fatal(err)
+	}
+	i = NewInterpreter(nil, nil)
+	i.Extension = Brainfork
+	if err := i.Interpret(code("Y[")); err == nil {
+		t.Fatal("expected nesting error")
+	}
+}
+
+func TestBrainforkTapeWrap(t *testing.T) {
+	out := &strings.Builder{}
+	i := NewInterpreter(out, nil)
+	i.Extension = Brainfork
+	i.Tape = TapeWrap
+	i.TapeSize = 2
+	// the child of the last cell starts at the first cell
+	if err := i.Interpret(code(">Y.")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\x01\x00" {
+		t.Fatalf("printed %q", out.String())
+	}
+}
@@ -0,0 +1,103 @@
+package bf
+
+import "container/list"
+
+// Cache remembers the output, runtime and error of executed programs, the
+// least recently used evaluation is dropped when the cache is full
+type Cache struct {
+	size   int
+	order  *list.List
+	items  map[cacheKey]*list.Element
+	hits   int
+	misses int
+}
+
+// cacheKey identifies an execution of a normalized program
+type cacheKey struct {
+	program    string
+	input      string
+	maxRuntime int
+}
+
+type cacheItem struct {
+	key     cacheKey
+	output  []byte
+	runtime int
+	err     error
+}
+
+// NewCache constructs a cache for the given number of evaluations
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:  size,
+		order: list.New(),
+		items: map[cacheKey]*list.Element{},
+	}
+}
+
+// Len is the number of cached evaluations
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.order.Len()
+}
+
+// Hits is the number of lookups that found an evaluation
+func (c *Cache) Hits() int {
+	if c == nil {
+		return 0
+	}
+	return c.hits
+}
+
+// Misses is the number of lookups that required an execution
+func (c *Cache) Misses() int {
+	if c == nil {
+		return 0
+	}
+	return c.misses
+}
+
+// HitRate is the ratio of lookups that found an evaluation
+func (c *Cache) HitRate() float64 {
+	return hitRate(c.Hits(), c.Misses())
+}
+
+func hitRate(hits, misses int) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// get looks up an evaluation, a nil cache never finds anything
+func (c *Cache) get(key cacheKey) (*cacheItem, bool) {
+	if c == nil {
+		return nil, false
+	}
+	if el, ok := c.items[key]; ok {
+		c.hits++
+		c.order.MoveToFront(el)
+		return el.Value.(*cacheItem), true
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *Cache) put(item *cacheItem) {
+	if c == nil || c.size <= 0 {
+		return
+	}
+	if el, ok := c.items[item.key]; ok {
+		el.Value = item
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[item.key] = c.order.PushFront(item)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+	}
+}
@@ -0,0 +1,75 @@
+package bf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCacheEviction(t *testing.T) {
+	c := NewCache(2)
+	a := cacheKey{program: "+."}
+	b := cacheKey{program: "++."}
+	d := cacheKey{program: "+++."}
+	c.put(&cacheItem{key: a, output: []byte{1}})
+	c.put(&cacheItem{key: b, output: []byte{2}})
+	if _, ok := c.get(a); !ok {
+		t.Fatal("expected hit")
+	}
+	c.put(&cacheItem{key: d, output: []byte{3}})
+	if _, ok := c.get(b); ok {
+		t.Fatal("least recently used not evicted")
+	}
+	if item, ok := c.get(a); !ok || item.output[0] != 1 || c.Len() != 2 {
+		t.Fatal("recently used evicted")
+	}
+	if c.Hits() != 2 || c.Misses() != 1 || c.HitRate() != 2.0/3.0 {
+		t.Fatalf("hits = %d misses = %d", c.Hits(), c.Misses())
+	}
+	var disabled *Cache
+	disabled.put(&cacheItem{key: a})
+	if _, ok := disabled.get(a); ok || disabled.HitRate() != 0 {
+		t.Fail()
+	}
+}
+
+func TestCachedEvaluation(t *testing.T) {
+	e := Entry{program: Program(`,+.]`)}
+	cache := NewCache(10)
+	cases := []TestCase{{Input: []byte("a"), Expected: []byte("b")}}
+	e.evaluate(cases, DefaultFitness, 100, cache)
+	e.evaluate(cases, DefaultFitness, 100, cache)
+	if !e.success || cache.Hits() != 1 || cache.Len() != 1 {
+		t.Fatalf("%s hits = %d", e.String(), cache.Hits())
+	}
+	// the normalized program is the key
+	e.program = Program(`,+.`)
+	e.evaluate(cases, DefaultFitness, 100, cache)
+	e.evaluate(cases, DefaultFitness, 50, cache)
+	if cache.Hits() != 2 || cache.Len() != 2 {
+		t.Fatalf("hits = %d len = %d", cache.Hits(), cache.Len())
+	}
+	// changing the output doesn't change the cached output
+	e.output[0] = 'x'
+	e.evaluate(cases, DefaultFitness, 50, cache)
+	if string(e.output) != "b" {
+		t.Fatalf("cached output changed to %q", e.output)
+	}
+}
+
+func TestCachedPopulation(t *testing.T) {
+	uncached := seededPopulation()
+	cached := seededPopulation()
+	cached.Cache = NewCache(1000)
+	for i := 0; i < 10; i++ {
+		uncached.EvaluateAndMutate()
+		cached.EvaluateAndMutate()
+	}
+	for i := range uncached.entries {
+		if !bytes.Equal(cached.entries[i].program, uncached.entries[i].program) {
+			t.Fatalf("entry %d differs", i)
+		}
+	}
+	if cached.Stats().CacheHitRate <= 0 || uncached.Stats().CacheHitRate != 0 {
+		t.Fatalf("hit rate %f", cached.Stats().CacheHitRate)
+	}
+}
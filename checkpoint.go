@@ -0,0 +1,191 @@
+package bf
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"math/rand"
+)
+
+const checkpointVersion = 2
+
+var errCheckpointVersion = errors.New("Unsupported checkpoint version")
+
+// checkpoint is the saved state of a population
+type checkpoint struct {
+	Version         int
+	Generation      int
+	RandomState     uint64
+	Expected        []byte
+	Cases           []TestCase
+	FitnessSpec     string
+	MaxRuntime      int
+	MaxManipulate   int
+	MultiObjective  bool
+	Simplify        bool
+	OperatorWeights map[string]float64
+	Adaptive        bool
+	Operators       []checkpointOperator
+	Diversity       Diversity
+	Archive         [][]byte
+	Entries         []checkpointEntry
+	Front           []checkpointEntry
+}
+
+type checkpointOperator struct {
+	Name               string
+	Attempts           int
+	Improvements       int
+	RecentAttempts     float64
+	RecentImprovements float64
+}
+
+type checkpointEntry struct {
+	Program       []byte
+	Output        []byte
+	Runtime       int
+	Error         string
+	Fitness       float64
+	Correctness   float64
+	Success       bool
+	Generation    int
+	Operators     []string
+	ParentFitness float64
+}
+
+func newCheckpointEntries(entries []Entry) []checkpointEntry {
+	saved := make([]checkpointEntry, len(entries))
+	for i, e := range entries {
+		saved[i] = checkpointEntry{
+			Program:       e.program,
+			Output:        e.output,
+			Runtime:       e.runtime,
+			Fitness:       e.fitness,
+			Correctness:   e.correctness,
+			Success:       e.success,
+			Generation:    e.generation,
+			Operators:     e.operators,
+			ParentFitness: e.parentFitness,
+		}
+		if e.err != nil {
+			saved[i].Error = e.err.Error()
+		}
+	}
+	return saved
+}
+
+func loadCheckpointEntries(saved []checkpointEntry) []Entry {
+	entries := make([]Entry, len(saved))
+	for i, e := range saved {
+		entries[i] = Entry{
+			program:       NewProgramClone(e.Program),
+			output:        e.Output,
+			runtime:       e.Runtime,
+			fitness:       e.Fitness,
+			correctness:   e.Correctness,
+			success:       e.Success,
+			generation:    e.Generation,
+			operators:     e.Operators,
+			parentFitness: e.ParentFitness,
+		}
+		if e.Error != "" {
+			entries[i].err = errors.New(e.Error)
+		}
+	}
+	return entries
+}
+
+func newCheckpointOperators(operators map[string]*OperatorStats) []checkpointOperator {
+	saved := []checkpointOperator{}
+	for _, name := range MutationOperators() {
+		if s, ok := operators[name]; ok {
+			saved = append(saved, checkpointOperator{
+				Name:               s.Name,
+				Attempts:           s.Attempts,
+				Improvements:       s.Improvements,
+				RecentAttempts:     s.recentAttempts,
+				RecentImprovements: s.recentImprovements,
+			})
+		}
+	}
+	return saved
+}
+
+func loadCheckpointOperators(saved []checkpointOperator) map[string]*OperatorStats {
+	operators := map[string]*OperatorStats{}
+	for _, s := range saved {
+		operators[s.Name] = &OperatorStats{
+			Name:               s.Name,
+			Attempts:           s.Attempts,
+			Improvements:       s.Improvements,
+			recentAttempts:     s.RecentAttempts,
+			recentImprovements: s.RecentImprovements,
+		}
+	}
+	return operators
+}
+
+// Save writes the entries, parameters, generation and random state of the
+// population, the fitness function is saved as its FitnessSpec
+func (p *Population) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(checkpoint{
+		Version:         checkpointVersion,
+		Generation:      p.generation,
+		RandomState:     p.src.state,
+		Expected:        p.Expected,
+		Cases:           p.Cases,
+		FitnessSpec:     p.FitnessSpec,
+		MaxRuntime:      p.MaxRuntime,
+		MaxManipulate:   p.MaxManipulate,
+		MultiObjective:  p.MultiObjective,
+		Simplify:        p.Simplify,
+		OperatorWeights: p.OperatorWeights,
+		Adaptive:        p.Adaptive,
+		Operators:       newCheckpointOperators(p.operators),
+		Diversity:       p.Diversity,
+		Archive:         p.archive,
+		Entries:         newCheckpointEntries(p.entries),
+		Front:           newCheckpointEntries(p.front),
+	})
+}
+
+// LoadPopulation reads a population written by Save, the fitness function is
+// rebuilt from the FitnessSpec and the default is used without a spec
+func LoadPopulation(r io.Reader) (*Population, error) {
+	var c checkpoint
+	if err := gob.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Version != checkpointVersion {
+		return nil, errCheckpointVersion
+	}
+	fitness := DefaultFitness
+	if c.FitnessSpec != "" {
+		f, err := FitnessByName(c.FitnessSpec)
+		if err != nil {
+			return nil, err
+		}
+		fitness = f
+	}
+	src := &source{state: c.RandomState}
+	return &Population{
+		entries:         loadCheckpointEntries(c.Entries),
+		Expected:        c.Expected,
+		Cases:           c.Cases,
+		Fitness:         fitness,
+		FitnessSpec:     c.FitnessSpec,
+		MaxRuntime:      c.MaxRuntime,
+		MaxManipulate:   c.MaxManipulate,
+		MultiObjective:  c.MultiObjective,
+		Simplify:        c.Simplify,
+		OperatorWeights: c.OperatorWeights,
+		Adaptive:        c.Adaptive,
+		operators:       loadCheckpointOperators(c.Operators),
+		Diversity:       c.Diversity,
+		archive:         c.Archive,
+		front:           loadCheckpointEntries(c.Front),
+		generation:      c.Generation,
+		src:             src,
+		rng:             rand.New(src),
+	}, nil
+}
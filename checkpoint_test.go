@@ -0,0 +1,79 @@
+package bf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func seededPopulation() *Population {
+	p := NewPopulation()
+	p.Seed(42)
+	p.Expected = []byte("hi\n")
+	p.MaxRuntime = 200
+	p.MaxManipulate = 2
+	return p
+}
+
+func TestSaveAndResume(t *testing.T) {
+	uninterrupted := seededPopulation()
+	for i := 0; i < 20; i++ {
+		uninterrupted.EvaluateAndMutate()
+	}
+
+	p := seededPopulation()
+	for i := 0; i < 10; i++ {
+		p.EvaluateAndMutate()
+	}
+	buf := &bytes.Buffer{}
+	if err := p.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := LoadPopulation(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Generation() != 10 || resumed.Fittest().String() != p.Fittest().String() {
+		t.Fatal("state not restored")
+	}
+	for i := 0; i < 10; i++ {
+		resumed.EvaluateAndMutate()
+	}
+
+	if resumed.Generation() != uninterrupted.Generation() {
+		t.Fatal("generation differs")
+	}
+	for i := range uninterrupted.entries {
+		if !bytes.Equal(resumed.entries[i].program, uninterrupted.entries[i].program) {
+			t.Fatalf("entry %d differs", i)
+		}
+	}
+}
+
+func TestResumeFitness(t *testing.T) {
+	p := seededPopulation()
+	p.FitnessSpec = "prefix:1,bits:0.5"
+	fitness, err := FitnessByName(p.FitnessSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Fitness = fitness
+	p.EvaluateAndMutate()
+	buf := &bytes.Buffer{}
+	if err := p.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := LoadPopulation(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := TestCase{Expected: []byte("hi\n")}
+	if resumed.FitnessSpec != p.FitnessSpec || resumed.Fitness.Fitness(Program(`+.`), []byte("hx"), 2, nil, c) != fitness.Fitness(Program(`+.`), []byte("hx"), 2, nil, c) {
+		t.Fatal("fitness not restored")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	if _, err := LoadPopulation(bytes.NewReader([]byte("invalid"))); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/sanderhahn/go-bf"
+)
+
+// formatMain implements the fmt subcommand
+func formatMain(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	var options bf.FormatOptions
+	var write bool
+	flags.BoolVar(&options.StripComments, "strip-comments", false, "remove all text that isn't an instruction")
+	flags.IntVar(&options.Width, "width", 0, "wrap the instructions at the column (0 disables wrapping)")
+	flags.StringVar(&options.Indent, "indent", "  ", "indent of every loop level (empty disables indentation)")
+	flags.BoolVar(&write, "w", false, "write the result to the program files instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: bf fmt [flags] [files]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	filenames := flags.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	for _, filename := range filenames {
+		if err := formatFile(filename, options, write); err != nil {
+			fatal(exitCode(err), err)
+		}
+	}
+}
+
+// formatFile formats the program in the file, - reads the program from stdin
+func formatFile(filename string, options bf.FormatOptions, write bool) error {
+	var program []byte
+	var err error
+	if filename == "-" {
+		program, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		program, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return err
+	}
+	formatted := bf.Format(program, options)
+	if write && filename != "-" {
+		return ioutil.WriteFile(filename, formatted, 0644)
+	}
+	_, err = os.Stdout.Write(formatted)
+	return err
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/sanderhahn/go-bf"
+)
+
+// fileIssue is the json representation of an issue in a file
+type fileIssue struct {
+	File string `json:"file"`
+	bf.Issue
+}
+
+// lintMain implements the lint subcommand, the exit code is one when issues
+// are found
+func lintMain(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	var asJSON bool
+	flags.BoolVar(&asJSON, "json", false, "print the issues as json array")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: bf lint [flags] [files]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	filenames := flags.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+	issues := []fileIssue{}
+	for _, filename := range filenames {
+		var source []byte
+		var err error
+		if filename == "-" {
+			source, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			source, err = ioutil.ReadFile(filename)
+		}
+		if err != nil {
+			fatal(exitIO, err)
+		}
+		for _, issue := range bf.Lint(source) {
+			issues = append(issues, fileIssue{File: filename, Issue: issue})
+		}
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			fatal(exitIO, err)
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s:%s\n", issue.File, issue.Issue)
+		}
+	}
+	if len(issues) > 0 {
+		os.Exit(exitIssues)
+	}
+}
@@ -2,23 +2,314 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/sanderhahn/go-bf"
 )
 
+// Exit codes per class of error
+const (
+	exitIssues  = 1
+	exitUsage   = 2
+	exitIO      = 3
+	exitSyntax  = 4
+	exitTape    = 5
+	exitLimit   = 6
+	exitTimeout = 7
+	exitRuntime = 8
+)
+
+// exitCode returns the exit code for the class of the error
+func exitCode(err error) int {
+	switch err {
+	case bf.ErrInvalidNesting:
+		return exitSyntax
+	case bf.ErrMemory, bf.ErrTape:
+		return exitTape
+	case bf.ErrExhaustedRuntime:
+		return exitLimit
+	case bf.ErrDeadline:
+		return exitTimeout
+	case bf.ErrUnsupported:
+		return exitUsage
+	}
+	if _, ok := err.(*os.PathError); ok {
+		return exitIO
+	}
+	return exitRuntime
+}
+
+// customDialect is the dialect named custom with the comma separated tokens
+func customDialect(tokens string) (*bf.Dialect, error) {
+	var t [8]string
+	fields := strings.Split(tokens, ",")
+	if len(fields) != len(t) {
+		return nil, fmt.Errorf("Expected %d comma separated tokens for ><+-.,[] but got %d", len(t), len(fields))
+	}
+	for n, field := range fields {
+		if t[n] = strings.TrimSpace(field); t[n] == "" {
+			return nil, fmt.Errorf("Empty token for %c", "><+-.,[]"[n])
+		}
+	}
+	return bf.NewDialect("custom", t, " "), nil
+}
+
+func fatal(code int, err error) {
+	log.Print(err)
+	os.Exit(code)
+}
+
+// Engines that run programs
+const (
+	engineAuto   = "auto"
+	engineStream = "stream"
+	engineFast   = "fast"
+)
+
+// runner runs programs with the settings of the flags
+type runner struct {
+	engine    string
+	extension bf.Extension
+	threads   int
+	dialect   *bf.Dialect
+	translate *bf.Dialect
+	separator bool
+	options   bf.Options
+	timeout   time.Duration
+	stats     bool
+	w         io.Writer
+	r         io.Reader
+}
+
+// useStream is true when the program needs the stream engine
+func (rn *runner) useStream() bool {
+	switch rn.engine {
+	case engineStream:
+		return true
+	case engineFast:
+		return false
+	}
+	return rn.extension != bf.NoExtension || rn.separator
+}
+
+// runFile runs the program in the file, - reads the program from stdin
+func (rn *runner) runFile(filename string) error {
+	if filename == "-" {
+		if rn.separator {
+			// the input follows the program
+			return rn.run(os.Stdin)
+		}
+		// the program is read completely so that stdin can be input
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return rn.run(bytes.NewReader(data))
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return rn.run(file)
+}
+
+func (rn *runner) run(code io.Reader) error {
+	var input io.Reader = bufio.NewReader(code)
+	if rn.translate != nil {
+		if err := bf.Translate(rn.w, input, rn.dialect, rn.translate); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(rn.w)
+		return err
+	}
+	if rn.dialect != bf.BrainfuckDialect {
+		// extended instructions are only available in brainfuck
+		if rn.separator {
+			input = rn.dialect.SeparatedReader(input)
+		} else {
+			input = rn.dialect.Reader(input)
+		}
+	}
+	options := rn.options
+	if rn.timeout > 0 {
+		options.Deadline = time.Now().Add(rn.timeout)
+	}
+	start := time.Now()
+	var stats bf.RunStats
+	var err error
+	if rn.useStream() {
+		i := bf.NewInterpreter(rn.w, rn.r)
+		i.Extension = rn.extension
+		i.MaxThreads = rn.threads
+		i.InputSeparator = rn.separator
+		i.Options = options
+		err = i.Interpret(input)
+		stats = i.RunStats()
+	} else {
+		var program *bf.Compiled
+		program, err = bf.ReadCompiled(input)
+		if err == nil {
+			stats, err = program.Run(rn.w, rn.r, options)
+		}
+	}
+	if rn.stats {
+		fmt.Fprintf(os.Stderr, "instructions: %d\ntime: %s\nmax tape: %d\n", stats.Instructions, time.Since(start), stats.MaxTape)
+	}
+	return err
+}
+
 func main() {
-	for _, filename := range os.Args[1:] {
-		file, err := os.Open(filename)
+	log.SetFlags(0)
+	log.SetPrefix("bf: ")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			formatMain(os.Args[2:])
+			return
+		case "lint":
+			lintMain(os.Args[2:])
+			return
+		}
+	}
+	var extension string
+	var maxThreads int
+	var dialect, translate, tokens string
+	var separator bool
+	var code string
+	var cellWidth, tapeSize, maxInstructions int
+	var eof, tape, engine string
+	var timeout time.Duration
+	var inputFile, outputFile string
+	var stats bool
+	flag.IntVar(&maxThreads, "threads", 0, "max brainfork threads (0 uses the default)")
+	flag.StringVar(&extension, "extension", "none", "extended dialect ("+strings.Join(bf.ExtensionNames(), ", ")+")")
+	flag.StringVar(&dialect, "dialect", "bf", "token substitution dialect of the program ("+strings.Join(bf.DialectNames(), ", ")+")")
+	flag.StringVar(&translate, "translate", "", "print the program in another dialect instead of running it")
+	flag.StringVar(&tokens, "tokens", "", "comma separated tokens for ><+-.,[] of the dialect named custom")
+	flag.BoolVar(&separator, "separator", false, "read the input from the program file after the first !")
+	flag.StringVar(&code, "e", "", "run the code instead of program files")
+	flag.IntVar(&cellWidth, "cell", 8, "cell width in bits (8, 16, 32)")
+	flag.StringVar(&eof, "eof", "zero", "cell value at end of input ("+strings.Join(bf.EOFPolicyNames(), ", ")+")")
+	flag.StringVar(&tape, "tape", "grow", "pointer beyond the tape ("+strings.Join(bf.TapePolicyNames(), ", ")+")")
+	flag.IntVar(&tapeSize, "tape-size", 0, "number of cells (0 is unlimited when growing and 30000 otherwise)")
+	flag.IntVar(&maxInstructions, "max-instructions", 0, "max executed instructions (0 is unlimited)")
+	flag.DurationVar(&timeout, "timeout", 0, "max running time of every program (0 is unlimited)")
+	flag.StringVar(&engine, "engine", engineAuto, "engine ("+strings.Join([]string{engineAuto, engineStream, engineFast}, ", ")+"), auto uses stream for extensions and the separator")
+	flag.StringVar(&inputFile, "i", "", "read the input from the file instead of stdin")
+	flag.StringVar(&outputFile, "o", "", "write the output to the file instead of stdout")
+	flag.BoolVar(&stats, "stats", false, "print instructions, time and max tape used to stderr")
+	flag.Parse()
+
+	rn := &runner{
+		engine:    engine,
+		threads:   maxThreads,
+		separator: separator,
+		timeout:   timeout,
+		stats:     stats,
+		options: bf.Options{
+			CellWidth:       cellWidth,
+			TapeSize:        tapeSize,
+			MaxInstructions: maxInstructions,
+		},
+	}
+	var err error
+	if rn.extension, err = bf.ExtensionByName(extension); err != nil {
+		fatal(exitUsage, err)
+	}
+	dialectByName := bf.DialectByName
+	if tokens != "" {
+		custom, err := customDialect(tokens)
+		if err != nil {
+			fatal(exitUsage, err)
+		}
+		dialectByName = func(name string) (*bf.Dialect, error) {
+			if name == custom.Name {
+				return custom, nil
+			}
+			return bf.DialectByName(name)
+		}
+	}
+	if rn.dialect, err = dialectByName(dialect); err != nil {
+		fatal(exitUsage, err)
+	}
+	if translate != "" {
+		if rn.translate, err = dialectByName(translate); err != nil {
+			fatal(exitUsage, err)
+		}
+	}
+	if rn.options.EOF, err = bf.EOFPolicyByName(eof); err != nil {
+		fatal(exitUsage, err)
+	}
+	if rn.options.Tape, err = bf.TapePolicyByName(tape); err != nil {
+		fatal(exitUsage, err)
+	}
+	if engine != engineAuto && engine != engineStream && engine != engineFast {
+		fatal(exitUsage, fmt.Errorf("Unknown engine %q", engine))
+	}
+	if engine == engineFast && (rn.extension != bf.NoExtension || separator) {
+		fatal(exitUsage, fmt.Errorf("Extensions and the separator require the stream engine"))
+	}
+	if code != "" && flag.NArg() > 0 {
+		fatal(exitUsage, fmt.Errorf("Use either -e or program files"))
+	}
+	if code == "" && flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	rn.r = os.Stdin
+	if inputFile != "" {
+		file, err := os.Open(inputFile)
+		if err != nil {
+			fatal(exitIO, err)
+		}
 		defer file.Close()
+		rn.r = file
+	}
+	rn.w = os.Stdout
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
 		if err != nil {
-			log.Fatal(err)
+			fatal(exitIO, err)
+		}
+		w := bufio.NewWriter(file)
+		rn.w = w
+		defer func() {
+			if err := w.Flush(); err != nil {
+				fatal(exitIO, err)
+			}
+			if err := file.Close(); err != nil {
+				fatal(exitIO, err)
+			}
+		}()
+	}
+
+	if err := rn.runAll(code); err != nil {
+		if w, ok := rn.w.(*bufio.Writer); ok {
+			w.Flush()
 		}
-		i := bf.NewInterpreter(os.Stdout, os.Stdin)
-		input := bufio.NewReader(file)
-		if err := i.Interpret(input); err != nil {
-			log.Fatal(err)
+		fatal(exitCode(err), err)
+	}
+}
+
+// runAll runs the inline code or the program files in order
+func (rn *runner) runAll(code string) error {
+	if code != "" {
+		return rn.run(strings.NewReader(code))
+	}
+	for _, filename := range flag.Args() {
+		if err := rn.runFile(filename); err != nil {
+			return err
 		}
 	}
+	return nil
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/sanderhahn/go-bf"
+)
+
+// source writes the constants as a go table
+func source(c *bf.Constants, name string) ([]byte, error) {
+	b := &bytes.Buffer{}
+	a := c.Assumptions
+	fmt.Fprintf(b, "// Code generated by bfconst; DO NOT EDIT.\n\n")
+	fmt.Fprintf(b, "package bf\n\n")
+	fmt.Fprintf(b, "// %s is the shortest known code for each value starting from %d\n", name, a.Start)
+	fmt.Fprintf(b, "// (cells to the right: %d, wrapping: %t)\n", a.Cells, a.Wrapping)
+	fmt.Fprintf(b, "var %s = [256]string{\n", name)
+	for v := 0; v < 256; v++ {
+		fmt.Fprintf(b, "\t%q, // 0x%02x\n", c.Code(byte(v)), v)
+	}
+	fmt.Fprintf(b, "}\n")
+	return format.Source(b.Bytes())
+}
+
+func main() {
+	a := bf.DefaultAssumptions
+	var start int
+	var gosource bool
+	var name, output string
+	flag.IntVar(&start, "start", int(a.Start), "known value of the cell")
+	flag.IntVar(&a.Cells, "cells", a.Cells, "zero cells available to the right")
+	flag.BoolVar(&a.Wrapping, "wrap", a.Wrapping, "allow values to wrap around")
+	flag.BoolVar(&gosource, "go", false, "write a go table")
+	flag.StringVar(&name, "name", "constantsTable", "name of the go table")
+	flag.StringVar(&output, "o", "", "output file (defaults to stdout)")
+	flag.Parse()
+	a.Start = byte(start)
+
+	c := bf.NewConstants(a)
+	var out []byte
+	if gosource {
+		var err error
+		if out, err = source(c, name); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		b := &bytes.Buffer{}
+		for v := 0; v < 256; v++ {
+			fmt.Fprintf(b, "0x%02x = %s\n", v, c.Code(byte(v)))
+		}
+		out = b.Bytes()
+	}
+	if output == "" {
+		os.Stdout.Write(out)
+	} else if err := ioutil.WriteFile(output, out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
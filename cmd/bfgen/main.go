@@ -2,53 +2,312 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
+	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/sanderhahn/go-bf"
 )
 
-const iterations = 10000
-
 func wrapAt(s string, at int) string {
-	b := bytes.NewBufferString("")
-	for pos := 0; pos < len(s); pos += at {
-		if pos+at < len(s) {
-			b.WriteString(s[pos : pos+at])
-			b.WriteRune('\n')
-		} else {
-			b.WriteString(s[pos:])
+	return bf.Wrap(s, at)
+}
+
+// testCase is the json representation of a test case
+type testCase struct {
+	Input    string `json:"input"`
+	Expected string `json:"expected"`
+}
+
+// readCases reads a json array of input and expected output pairs
+func readCases(filename string) ([]bf.TestCase, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cases []testCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, err
+	}
+	testCases := make([]bf.TestCase, len(cases))
+	for i, c := range cases {
+		testCases[i] = bf.TestCase{Input: []byte(c.Input), Expected: []byte(c.Expected)}
+	}
+	return testCases, nil
+}
+
+// saveCheckpoint writes the population to a temporary file that replaces
+// the checkpoint when complete
+func saveCheckpoint(population *bf.Population, filename string) error {
+	tmp := filename + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := population.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// fileList collects the values of a repeatable flag
+type fileList []string
+
+func (l *fileList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *fileList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// readPrograms reads a program from every file
+func readPrograms(filenames []string) ([]bf.Program, error) {
+	programs := []bf.Program{}
+	for _, filename := range filenames {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
 		}
+		program, err := bf.ReadProgram(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, program)
+	}
+	return programs, nil
+}
+
+func loadCheckpoint(filename string) (*bf.Population, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
 	}
-	return b.String()
+	defer file.Close()
+	return bf.LoadPopulation(file)
 }
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
 
-	expected, err := ioutil.ReadAll(os.Stdin)
+	var maxRuntime, maxManipulate int
+	var multiObjective, simplify bool
+	var casesFile string
+	var fitness string
+	var seed int64
+	var checkpoint, resume string
+	var checkpointEvery int
+	var report string
+	var criteria bf.Criteria
+	var minimize int
+	var method string
+	var operators string
+	var adaptive bool
+	var cacheSize int
+	var seedFiles fileList
+	var diversity string
+	flag.IntVar(&maxRuntime, "runtime", 10000, "max runtime for program")
+	flag.IntVar(&maxManipulate, "manipulate", 3, "max manipulation when copying")
+	flag.StringVar(&casesFile, "cases", "", "json file with input and expected output test cases")
+	flag.BoolVar(&multiObjective, "pareto", false, "optimize correctness, length and runtime and print the pareto front")
+	flag.BoolVar(&simplify, "simplify", false, "remove code that doesn't change the output")
+	flag.StringVar(&fitness, "fitness", "default", "fitness function ("+strings.Join(bf.FitnessNames(), ", ")+") or weighted list name:weight,...")
+	flag.Int64Var(&seed, "seed", 0, "random seed (defaults to current time)")
+	flag.StringVar(&checkpoint, "checkpoint", "", "file to save the population to")
+	flag.IntVar(&checkpointEvery, "checkpoint-every", 100, "generations between checkpoints")
+	flag.StringVar(&resume, "resume", "", "checkpoint file to continue from")
+	flag.StringVar(&report, "report", "text", "progress report format (text, json, csv)")
+	flag.IntVar(&criteria.MaxGenerations, "generations", 10000, "max generations (0 is unlimited)")
+	flag.DurationVar(&criteria.MaxDuration, "duration", 0, "max wall-clock duration (0 is unlimited)")
+	flag.BoolVar(&criteria.StopOnSuccess, "stop-on-success", false, "stop when the first successful program is found")
+	flag.IntVar(&criteria.MaxStagnation, "stagnation", 0, "stop after generations without improvement (0 is unlimited)")
+	flag.IntVar(&criteria.TargetLength, "target-length", 0, "stop when a successful program is at most this length")
+	flag.IntVar(&minimize, "minimize", 0, "random mutations attempted when minimizing the successful program (0 disables minimization)")
+	flag.StringVar(&method, "method", "evolve", "generate programs using genetic programming (evolve) or directly for the text (direct)")
+	flag.StringVar(&operators, "operators", "", "mutation operator weights name=weight,... ("+strings.Join(bf.MutationOperators(), ", ")+")")
+	flag.BoolVar(&adaptive, "adaptive", false, "adapt operator weights to their recent success")
+	flag.IntVar(&cacheSize, "cache", 100000, "evaluations remembered for repeated programs (0 disables the cache)")
+	flag.Var(&seedFiles, "seed-file", "file with a program to seed the population with (repeatable)")
+	flag.StringVar(&diversity, "diversity", "none", "diversity mechanism ("+strings.Join(bf.DiversityNames(), ", ")+")")
+	flag.Parse()
+
+	switch method {
+	case "evolve":
+	case "direct":
+		expected, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s\n", wrapAt(string(bf.Generate(expected)), 80))
+		return
+	default:
+		log.Fatalf("Unknown method %q (choose from evolve, direct)", method)
+	}
+
+	f, err := bf.FitnessByName(fitness)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	population := bf.NewPopulation()
-	population.Expected = expected
+	weights, err := bf.ParseOperatorWeights(operators)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	flag.IntVar(&population.MaxRuntime, "runtime", 10000, "max runtime for program")
-	flag.IntVar(&population.MaxManipulate, "manipulate", 3, "max manipulation when copying")
-	flag.Parse()
+	d, err := bf.DiversityByName(diversity)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var population *bf.Population
+	if resume != "" {
+		population, err = loadCheckpoint(resume)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// only explicitly passed parameters override the checkpoint
+		flag.Visit(func(fl *flag.Flag) {
+			switch fl.Name {
+			case "runtime":
+				population.MaxRuntime = maxRuntime
+			case "manipulate":
+				population.MaxManipulate = maxManipulate
+			case "pareto":
+				population.MultiObjective = multiObjective
+			case "simplify":
+				population.Simplify = simplify
+			case "fitness":
+				population.Fitness = f
+				population.FitnessSpec = fitness
+			case "operators":
+				population.OperatorWeights = weights
+			case "adaptive":
+				population.Adaptive = adaptive
+			case "diversity":
+				population.Diversity = d
+			}
+		})
+	} else {
+		population = bf.NewPopulation()
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		population.MaxRuntime = maxRuntime
+		population.MaxManipulate = maxManipulate
+		population.MultiObjective = multiObjective
+		population.Simplify = simplify
+		population.Fitness = f
+		population.FitnessSpec = fitness
+		population.OperatorWeights = weights
+		population.Adaptive = adaptive
+		population.Diversity = d
+		if casesFile != "" {
+			cases, err := readCases(casesFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			population.Cases = cases
+		} else {
+			expected, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				log.Fatal(err)
+			}
+			population.Expected = expected
+		}
+		population.Seed(seed)
+		programs, err := readPrograms(seedFiles)
+		if err != nil {
+			log.Fatal(err)
+		}
+		population.SeedPrograms(programs)
+	}
+	if cacheSize > 0 {
+		population.Cache = bf.NewCache(cacheSize)
+	}
+
+	observer, err := reporter(report, os.Stdout, population)
+	if err != nil {
+		log.Fatal(err)
+	}
+	population.Observe(observer)
+
+	// codes are written to stderr when stdout is used for json or csv
+	codeOutput := os.Stdout
+	if report != "text" {
+		codeOutput = os.Stderr
+	}
+
+	// print the code when it changes
+	var lastCode bf.Program
+	population.Observe(func(stats bf.Stats) {
+		if code, ok := population.SuccessCode(); ok && !bytes.Equal(code, lastCode) {
+			fmt.Fprintf(codeOutput, "%s\n", wrapAt(string(code), 80))
+			lastCode = code
+		}
+	})
+	if checkpoint != "" && checkpointEvery > 0 {
+		population.Observe(func(stats bf.Stats) {
+			if stats.Generation%checkpointEvery == 0 {
+				if err := saveCheckpoint(population, checkpoint); err != nil {
+					log.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// stop gracefully on interrupt
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	result := population.Run(ctx, criteria)
+	if checkpoint != "" {
+		if err := saveCheckpoint(population, checkpoint); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Fprintf(codeOutput, "stopped after %d generations (%s) in %s\n", result.Generations, result.Reason, result.Duration)
+	if population.Cache != nil {
+		fmt.Fprintf(codeOutput, "cache hits = %d misses = %d rate = %.4f\n", population.Cache.Hits(), population.Cache.Misses(), population.Cache.HitRate())
+	}
+	for _, s := range population.OperatorStats() {
+		fmt.Fprintf(codeOutput, "operator %-19s weight = %.3f attempts = %d improvements = %d rate = %.4f\n", s.Name, s.Weight, s.Attempts, s.Improvements, s.Rate())
+	}
+
+	if result.Success && minimize > 0 {
+		code := population.Minimize(result.Code, minimize, func(code bf.Program) {
+			fmt.Fprintf(codeOutput, "minimized length = %d\n", len(code))
+		})
+		fmt.Fprintf(codeOutput, "%s\n", wrapAt(string(code), 80))
+	}
 
-	for i := 1; i <= iterations; i++ {
-		population.EvaluateAndMutate()
-		fittest := population.Fittest()
-		fmt.Printf("%d: %s\n", i, fittest)
-		if code, ok := population.SuccessCode(); ok {
-			fmt.Printf("%s\n", wrapAt(string(code), 80))
+	if population.MultiObjective {
+		for _, entry := range population.Front() {
+			code := entry.Program()
+			if population.Simplify {
+				if simplified := bf.Simplify(code); population.Verify(simplified) {
+					code = simplified
+				}
+			}
+			fmt.Fprintf(codeOutput, "length = %d runtime = %d\n%s\n", len(code), entry.Runtime(), wrapAt(string(code), 80))
 		}
 	}
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/sanderhahn/go-bf"
+)
+
+// reporter returns an observer that writes the progress of the population
+// in text, json lines or csv format
+func reporter(format string, w io.Writer, population *bf.Population) (bf.Observer, error) {
+	switch format {
+	case "text":
+		return func(stats bf.Stats) {
+			fmt.Fprintf(w, "%d: %s\n", stats.Generation, population.Fittest())
+		}, nil
+	case "json":
+		encoder := json.NewEncoder(w)
+		return func(stats bf.Stats) {
+			if err := encoder.Encode(stats); err != nil {
+				log.Fatal(err)
+			}
+		}, nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := true
+		return func(stats bf.Stats) {
+			if header {
+				writer.Write(bf.StatsHeader)
+				header = false
+			}
+			writer.Write(stats.Record())
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				log.Fatal(err)
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("Unknown report format %q (choose from text, json, csv)", format)
+}
@@ -0,0 +1,388 @@
+package bf
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+)
+
+type opcode byte
+
+const (
+	opAdd opcode = iota
+	opMove
+	opOutput
+	opInput
+	opOpen
+	opClose
+	opClear
+	opMultiply
+)
+
+// op is a run of instructions, arg is the amount to add or move for runs,
+// the matching bracket for loops, the inverse of the amount for clears and
+// the multiplication for multiplication loops
+type op struct {
+	code opcode
+	// iteration is the number of instructions of an iteration of a clear or
+	// multiplication loop
+	iteration int32
+	arg       int
+	count     int // number of instructions
+	// low and high are the furthest moves of a run or multiplication loop to
+	// the left and right, so that the tape is checked like for every single
+	// move
+	low, high int32
+}
+
+// product is the change of a cell per iteration of a multiplication loop
+type product struct {
+	offset int
+	delta  uint32
+}
+
+// multiplication is a loop that adds multiples of the condition cell to
+// other cells
+type multiplication struct {
+	inverse  uint32 // inverse of the change of the condition cell
+	products []product
+}
+
+// Compiled program for the fast engine, runs of instructions are combined
+// and the loops jump directly to their matching bracket. Loops that only
+// add multiples of the condition cell to other cells run at once.
+type Compiled struct {
+	ops             []op
+	multiplications []multiplication
+}
+
+// opened is a loop that hasn't been closed yet
+type opened struct {
+	op   int
+	loop *Loop
+}
+
+// Compile the program, comments are skipped and the loops must be balanced
+func Compile(program []byte) (*Compiled, error) {
+	loops, err := AnalyzeLoops(program)
+	if err != nil {
+		return nil, err
+	}
+	c := &Compiled{}
+	stack := []opened{}
+	for _, b := range program {
+		switch b {
+		case '+', '-', '>', '<':
+			code, arg := opAdd, 1
+			if b == '>' || b == '<' {
+				code = opMove
+			}
+			if b == '-' || b == '<' {
+				arg = -1
+			}
+			n := len(c.ops) - 1
+			if n < 0 || c.ops[n].code != code {
+				c.ops = append(c.ops, op{code: code})
+				n++
+			}
+			o := &c.ops[n]
+			o.arg += arg
+			o.count++
+			if int32(o.arg) < o.low {
+				o.low = int32(o.arg)
+			}
+			if int32(o.arg) > o.high {
+				o.high = int32(o.arg)
+			}
+		case '.':
+			c.ops = append(c.ops, op{code: opOutput, count: 1})
+		case ',':
+			c.ops = append(c.ops, op{code: opInput, count: 1})
+		case '[':
+			stack = append(stack, opened{op: len(c.ops), loop: loops[0]})
+			loops = loops[1:]
+			c.ops = append(c.ops, op{code: opOpen, count: 1})
+		case ']':
+			o := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			open, loop := o.op, o.loop
+			// balanced loops without nested loops and I/O that change the
+			// condition by an odd amount end after a computable number of
+			// iterations, the instructions of the iterations are counted at
+			// once
+			if loop.Deltas != nil && !loop.Output && loop.Deltas[0]%2 != 0 {
+				if body := c.ops[open+1:]; len(body) == 1 {
+					// [-] and [+] clear the cell
+					c.ops = append(c.ops[:open], op{
+						code:      opClear,
+						arg:       int(inverse32(uint32(body[0].arg))),
+						count:     1,
+						iteration: int32(body[0].count + 1),
+					})
+					continue
+				}
+				c.multiply(open)
+				open++
+			}
+			c.ops[open].arg = len(c.ops)
+			c.ops = append(c.ops, op{code: opClose, arg: open, count: 1})
+		}
+	}
+	return c, nil
+}
+
+// multiply inserts a multiplication before the loop at open, the loop
+// remains for when the multiplication would leave the memory
+func (c *Compiled) multiply(open int) {
+	m := op{code: opMultiply, arg: len(c.multiplications), iteration: 1}
+	deltas := map[int]uint32{}
+	ptr := 0
+	for _, o := range c.ops[open+1:] {
+		m.iteration += int32(o.count)
+		if o.code == opAdd {
+			deltas[ptr] += uint32(o.arg)
+			continue
+		}
+		if int32(ptr)+o.low < m.low {
+			m.low = int32(ptr) + o.low
+		}
+		if int32(ptr)+o.high > m.high {
+			m.high = int32(ptr) + o.high
+		}
+		ptr += o.arg
+	}
+	mul := multiplication{inverse: inverse32(deltas[0])}
+	for offset, delta := range deltas {
+		if offset != 0 {
+			mul.products = append(mul.products, product{offset: offset, delta: delta})
+		}
+	}
+	c.multiplications = append(c.multiplications, mul)
+	c.ops = append(c.ops[:open+1], c.ops[open:]...)
+	c.ops[open] = m
+}
+
+// inverse32 is the multiplicative inverse of an odd number modulo 2^32
+func inverse32(a uint32) uint32 {
+	inv := a
+	for n := 0; n < 5; n++ {
+		inv *= 2 - a*inv
+	}
+	return inv
+}
+
+// ReadCompiled reads and compiles the program
+func ReadCompiled(r io.Reader) (*Compiled, error) {
+	program, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(program)
+}
+
+// tape is the memory of the fast engine, the cells are masked to their width
+type tape struct {
+	memory []uint32
+	mask   uint32
+}
+
+func (t *tape) set(p int, v uint32) {
+	t.memory[p] = v & t.mask
+}
+
+// read a byte of input into the cell according to the EOF policy
+func (t *tape) read(p int, r *bufio.Reader, eof EOFPolicy) error {
+	if r != nil {
+		b, err := r.ReadByte()
+		if err == nil {
+			t.set(p, uint32(b))
+			return nil
+		}
+		if err != io.EOF {
+			return err
+		}
+	}
+	switch eof {
+	case EOFZero:
+		t.set(p, 0)
+	case EOFMinusOne:
+		t.set(p, t.mask)
+	}
+	return nil
+}
+
+// moveRun moves the pointer by a run of moves that leaves the memory,
+// returns the pointer and the rightmost cell that was used
+func (t *tape) moveRun(ptr int, run *op, o Options) (int, int, error) {
+	cells := len(t.memory)
+	if o.Tape == TapeWrap {
+		return ((ptr+run.arg)%cells + cells) % cells, cells - 1, nil
+	}
+	low, high := ptr+int(run.low), ptr+int(run.high)
+	if low < 0 {
+		return ptr, ptr, ErrMemory
+	}
+	if _, err := t.move(ptr, int(run.high), o); err != nil {
+		return ptr, ptr, err
+	}
+	return ptr + run.arg, high, nil
+}
+
+// move the pointer according to the tape policy
+func (t *tape) move(ptr, n int, o Options) (int, error) {
+	ptr += n
+	cells := len(t.memory)
+	switch {
+	case ptr >= 0 && ptr < cells:
+		return ptr, nil
+	case o.Tape == TapeWrap:
+		return (ptr%cells + cells) % cells, nil
+	case ptr < 0:
+		return ptr, ErrMemory
+	case o.Tape == TapeError:
+		return ptr, ErrTape
+	}
+	// increase memory on demand
+	if o.TapeSize > 0 && ptr >= o.TapeSize {
+		return ptr, ErrTape
+	}
+	grow := cells
+	if grow < memorySize {
+		grow = memorySize
+	}
+	for cells+grow <= ptr {
+		grow *= 2
+	}
+	if o.TapeSize > 0 && cells+grow > o.TapeSize {
+		grow = o.TapeSize - cells
+	}
+	t.memory = append(t.memory, make([]uint32, grow)...)
+	return ptr, nil
+}
+
+// Run the program with the options, the output is buffered until a line
+// is complete, the program reads input or ends
+func (c *Compiled) Run(w io.Writer, r io.Reader, o Options) (RunStats, error) {
+	stats := RunStats{}
+	width, err := o.cellBytes()
+	if err != nil {
+		return stats, err
+	}
+	cells := memorySize
+	if o.Tape != TapeGrow {
+		cells = o.tapeSize()
+	} else if o.TapeSize > 0 && o.TapeSize < cells {
+		cells = o.TapeSize
+	}
+	t := &tape{memory: make([]uint32, cells), mask: uint32(1)<<(8*uint(width)) - 1}
+	bw := bufio.NewWriter(w)
+	var br *bufio.Reader
+	if r != nil {
+		br = bufio.NewReader(r)
+	}
+	err = c.run(t, bw, br, o, &stats)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return stats, err
+}
+
+func (c *Compiled) run(t *tape, w *bufio.Writer, r *bufio.Reader, o Options, stats *RunStats) (err error) {
+	ptr, maxPtr := 0, 0
+	instructions, steps := 0, 0
+	limit := o.MaxInstructions
+	defer func() {
+		stats.Instructions = instructions
+		stats.MaxTape = maxPtr + 1
+	}()
+	ops, memory, mask := c.ops, t.memory, t.mask
+	for pc := 0; pc < len(ops); pc++ {
+		instr := &ops[pc]
+		instructions += instr.count
+		if limit > 0 && instructions > limit {
+			instructions = limit
+			return ErrExhaustedRuntime
+		}
+		steps++
+		if steps == deadlineInterval {
+			steps = 0
+			if o.expired() {
+				return ErrDeadline
+			}
+		}
+		switch instr.code {
+		case opAdd:
+			memory[ptr] = (memory[ptr] + uint32(instr.arg)) & mask
+		case opMove:
+			high := ptr + int(instr.high)
+			if ptr+int(instr.low) < 0 || high >= len(memory) {
+				if ptr, high, err = t.moveRun(ptr, instr, o); err != nil {
+					return err
+				}
+				memory = t.memory
+			} else {
+				ptr += instr.arg
+			}
+			if high > maxPtr {
+				maxPtr = high
+			}
+		case opOutput:
+			b := byte(memory[ptr])
+			if err := w.WriteByte(b); err != nil {
+				return err
+			}
+			if b == '\n' {
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+		case opInput:
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if err := t.read(ptr, r, o.EOF); err != nil {
+				return err
+			}
+		case opOpen:
+			if memory[ptr] == 0 {
+				pc = instr.arg
+			}
+		case opClose:
+			if memory[ptr] != 0 {
+				pc = instr.arg
+			}
+		case opClear:
+			// iterations until the cell is zero
+			n := int(-memory[ptr] * uint32(instr.arg) & mask)
+			instructions += n * int(instr.iteration)
+			if limit > 0 && instructions > limit {
+				instructions = limit
+				return ErrExhaustedRuntime
+			}
+			memory[ptr] = 0
+		case opMultiply:
+			// iterations until the cell is zero
+			mul := &c.multiplications[instr.arg]
+			n := int(-memory[ptr] * mul.inverse & mask)
+			high := ptr + int(instr.high)
+			cost := 1 + n*int(instr.iteration)
+			if n > 0 && (ptr+int(instr.low) < 0 || high >= len(memory)) ||
+				limit > 0 && instructions+cost > limit {
+				// the loop runs to leave the memory or exhaust the runtime
+				// at the same instruction as the stream engine
+				break
+			}
+			instructions += cost
+			for _, p := range mul.products {
+				memory[ptr+p.offset] = (memory[ptr+p.offset] + uint32(n)*p.delta) & mask
+			}
+			memory[ptr] = 0
+			if n > 0 && high > maxPtr {
+				maxPtr = high
+			}
+			// continue after the loop
+			pc = ops[pc+1].arg
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package bf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompile(t *testing.T) {
+	for _, program := range []string{`[`, `]`, `+[`, `][`} {
+		if _, err := Compile([]byte(program)); err != ErrInvalidNesting {
+			t.Errorf("%s: expected invalid nesting, got %v", program, err)
+		}
+	}
+	c, err := Compile([]byte(`+++ comment >>< [-] [>+<-]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.ops) != 10 || c.ops[2].code != opClear || c.ops[3].code != opMultiply || c.ops[4].arg != 9 || c.ops[9].arg != 4 {
+		t.Fatalf("unexpected ops %v", c.ops)
+	}
+}
+
+func TestEngines(t *testing.T) {
+	for _, filename := range []string{"examples/hello.bf", "examples/rot13.bf", "examples/fib.bf"} {
+		program, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := "Hello\n"
+		stream := &strings.Builder{}
+		i := NewInterpreter(stream, strings.NewReader(input))
+		i.MaxInstructions = 10000000
+		streamErr := i.Interpret(bytes.NewReader(program))
+
+		c, err := Compile(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fast := &strings.Builder{}
+		stats, fastErr := c.Run(fast, strings.NewReader(input), Options{MaxInstructions: 10000000})
+		if fast.String() != stream.String() || (fastErr == nil) != (streamErr == nil) {
+			t.Errorf("%s: %q (%v) != %q (%v)", filename, fast.String(), fastErr, stream.String(), streamErr)
+		}
+		if stats != i.RunStats() {
+			t.Errorf("%s: %+v != %+v", filename, stats, i.RunStats())
+		}
+	}
+}
+
+func runCompiled(t *testing.T, program string, input string, o Options) (string, RunStats, error) {
+	c, err := Compile([]byte(program))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &strings.Builder{}
+	stats, err := c.Run(out, strings.NewReader(input), o)
+	return out.String(), stats, err
+}
+
+func TestCellWidth(t *testing.T) {
+	// prints one when the cell didn't wrap to zero
+	wrapped := func(n int) string {
+		return strings.Repeat("+", n) + `[>+<[-]]>.`
+	}
+	expected := map[int]string{8: "\x00\x00", 16: "\x01\x00", 32: "\x01\x01"}
+	for width, want := range expected {
+		out, _, err := runCompiled(t, wrapped(256)+`>`+wrapped(65536), "", Options{CellWidth: width})
+		if err != nil || out != want {
+			t.Errorf("%d bits: %q != %q (%v)", width, out, want, err)
+		}
+		// minus one wraps to zero
+		out, _, err = runCompiled(t, `,+[>+<[-]]>.`, "", Options{CellWidth: width, EOF: EOFMinusOne})
+		if err != nil || out != "\x00" {
+			t.Errorf("%d bits: minus one %q (%v)", width, out, err)
+		}
+	}
+	if _, _, err := runCompiled(t, `+`, "", Options{CellWidth: 12}); err != ErrUnsupported {
+		t.Errorf("expected unsupported, got %v", err)
+	}
+}
+
+func TestMultiplication(t *testing.T) {
+	tests := []struct {
+		program string
+		options Options
+	}{
+		{`+++[>++>+++<<-]>.>.`, Options{}},
+		{`+++++[>+<---]>.`, Options{}},
+		{`-[>+<-]>.`, Options{}},
+		{`>+[>>+<<-]<.`, Options{}},
+		{`+[<+>-]`, Options{}},
+		{`+[<+>-]<.`, Options{Tape: TapeWrap, TapeSize: 3}},
+		{`+[>+<-]`, Options{Tape: TapeError, TapeSize: 1}},
+		{`+++[>+<-]`, Options{MaxInstructions: 8}},
+	}
+	for _, test := range tests {
+		out := &strings.Builder{}
+		i := NewInterpreter(out, nil)
+		i.Options = test.options
+		err := i.Interpret(code(test.program))
+		fastOut, stats, fastErr := runCompiled(t, test.program, "", test.options)
+		if fastOut != out.String() || fastErr != err || stats != i.RunStats() {
+			t.Errorf("%s: fast %q %+v %v, stream %q %+v %v", test.program, fastOut, stats, fastErr, out.String(), i.RunStats(), err)
+		}
+	}
+	out, stats, err := runCompiled(t, `-[>+<-]>.`, "", Options{CellWidth: 16})
+	if err != nil || out != "\xff" || stats.Instructions != 4+65535*5 || stats.MaxTape != 2 {
+		t.Errorf("16 bit multiplication %q %+v %v", out, stats, err)
+	}
+}
+
+func TestRunOptions(t *testing.T) {
+	out, _, err := runCompiled(t, `+++++,.`, "", Options{EOF: EOFUnchanged})
+	if err != nil || out != "\x05" {
+		t.Errorf("unchanged %q (%v)", out, err)
+	}
+	out, _, err = runCompiled(t, `<+++>>>.`, "", Options{Tape: TapeWrap, TapeSize: 3})
+	if err != nil || out != "\x03" {
+		t.Errorf("wrap %q (%v)", out, err)
+	}
+	_, stats, err := runCompiled(t, `+[>+]`, "", Options{Tape: TapeError, TapeSize: 10})
+	if err != ErrTape || stats.MaxTape != 10 {
+		t.Errorf("expected tape error at 10 cells, got %v %d", err, stats.MaxTape)
+	}
+	if _, _, err := runCompiled(t, `+[>+]`, "", Options{TapeSize: 2000}); err != ErrTape {
+		t.Errorf("expected tape error when growing, got %v", err)
+	}
+	// runs of moves are checked like single moves
+	for _, program := range []string{`<`, `<>`, `><<>`} {
+		if _, _, err := runCompiled(t, program, "", Options{}); err != ErrMemory {
+			t.Errorf("%s: expected memory error, got %v", program, err)
+		}
+	}
+	if _, _, err := runCompiled(t, `>><<`, "", Options{Tape: TapeError, TapeSize: 2}); err != ErrTape {
+		t.Errorf("expected tape error, got %v", err)
+	}
+	_, stats, err = runCompiled(t, `+++++[-]>`, "", Options{})
+	if err != nil || stats.Instructions != 17 || stats.MaxTape != 2 {
+		t.Errorf("clear counted as %+v (%v)", stats, err)
+	}
+	if _, _, err := runCompiled(t, `+ + + .`, "", Options{MaxInstructions: 4}); err != nil {
+		t.Errorf("expected the program to complete, got %v", err)
+	}
+	_, stats, err = runCompiled(t, `+[]`, "", Options{MaxInstructions: 100})
+	if err != ErrExhaustedRuntime || stats.Instructions != 100 {
+		t.Errorf("expected exhausted runtime, got %v after %d", err, stats.Instructions)
+	}
+	if _, _, err := runCompiled(t, `+[]`, "", Options{Deadline: time.Now().Add(10 * time.Millisecond)}); err != ErrDeadline {
+		t.Errorf("expected deadline, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package bf
+
+import "bytes"
+
+//go:generate go run ./cmd/bfconst -go -o constants_table.go
+
+// Assumptions under which the code for constants is computed
+type Assumptions struct {
+	// Start is the known value of the cell
+	Start byte
+	// Cells is the number of zero cells available to the right of the cell
+	Cells int
+	// Wrapping allows values to wrap around between 0 and 255
+	Wrapping bool
+}
+
+// DefaultAssumptions start from zero with one cell to the right and wrapping
+var DefaultAssumptions = Assumptions{Start: 0, Cells: 1, Wrapping: true}
+
+// Constants contains the code that sets a cell to each value
+type Constants struct {
+	Assumptions Assumptions
+	codes       [256]Program
+}
+
+// DefaultConstants is the precomputed table for the default assumptions
+var DefaultConstants = newConstantsTable(DefaultAssumptions, constantsTable)
+
+func newConstantsTable(a Assumptions, table [256]string) *Constants {
+	c := &Constants{Assumptions: a}
+	for v, code := range table {
+		c.codes[v] = Program(code)
+	}
+	return c
+}
+
+// Code returns the code that sets the cell to the value, the pointer and the
+// cells to the right are restored afterwards
+func (c *Constants) Code(value byte) Program {
+	return c.codes[value]
+}
+
+// maxLoopChanges limits the changes of counters and bodies of nested loops
+const maxLoopChanges = 16
+
+// NewConstants computes the shortest code for setting a cell to each value.
+// The code is the shortest of the following shapes: only changes of the cell
+// (`+++`), a multiplication loop (`>+++[<++++>-]<+`) when one cell is available
+// and nested multiplication loops (`>>++[<+++[<++++>-]>-]<<+`) when two cells
+// are available. Nested loops are searched using at most 16 changes for
+// counters and bodies.
+func NewConstants(a Assumptions) *Constants {
+	c := &Constants{Assumptions: a}
+	// shortest loop that results in each intermediate value
+	var loops [256]Program
+	consider := func(counters []int, body int) {
+		product := body
+		length := 7*len(counters) + a.changesLength(body)
+		for _, n := range counters {
+			product *= n
+			length += a.changesLength(n)
+		}
+		value := int(a.Start) + product
+		if !a.Wrapping && (value < 0 || value > 255) {
+			return
+		}
+		v := byte(value)
+		if loops[v] == nil || length < len(loops[v]) {
+			loops[v] = a.loopCode(counters, body)
+		}
+	}
+	bodies := []int{}
+	for b := -255; b <= 255; b++ {
+		if b != 0 && (!a.Wrapping || (b >= -128 && b < 128)) {
+			bodies = append(bodies, b)
+		}
+	}
+	if a.Cells >= 1 {
+		for n := 1; n < 256; n++ {
+			for _, b := range bodies {
+				consider([]int{n}, b)
+			}
+		}
+	}
+	if a.Cells >= 2 {
+		for n1 := 1; n1 < 256; n1++ {
+			if a.changesLength(n1) > maxLoopChanges {
+				continue
+			}
+			for n2 := 1; n2 < 256; n2++ {
+				if a.changesLength(n2) > maxLoopChanges {
+					continue
+				}
+				for _, b := range bodies {
+					if a.changesLength(b) <= maxLoopChanges {
+						consider([]int{n1, n2}, b)
+					}
+				}
+			}
+		}
+	}
+	for target := 0; target < 256; target++ {
+		t := byte(target)
+		best := a.changes(a.Start, t)
+		for v, loop := range loops {
+			if loop == nil {
+				continue
+			}
+			if length := len(loop) + len(a.changes(byte(v), t)); length < len(best) {
+				best = append(NewProgramClone(loop), a.changes(byte(v), t)...)
+			}
+		}
+		c.codes[target] = best
+	}
+	return c
+}
+
+// changes returns the `+` or `-` instructions that change from to the value to
+func (a Assumptions) changes(from, to byte) Program {
+	if a.Wrapping {
+		return adjust(from, to)
+	}
+	if to >= from {
+		return Program(bytes.Repeat([]byte{'+'}, int(to-from)))
+	}
+	return Program(bytes.Repeat([]byte{'-'}, int(from-to)))
+}
+
+// changesLength returns the number of changes needed to add delta to zero
+func (a Assumptions) changesLength(delta int) int {
+	if a.Wrapping {
+		return adjustLength(0, byte(delta))
+	}
+	if delta < 0 {
+		return -delta
+	}
+	return delta
+}
+
+// loopCode returns nested loops with counters to the right that repeat
+// adding the body to the cell
+func (a Assumptions) loopCode(counters []int, body int) Program {
+	depth := len(counters)
+	code := Program(bytes.Repeat([]byte{'>'}, depth))
+	for i, n := range counters {
+		if i > 0 {
+			code = append(code, `[<`...)
+		}
+		code = append(code, a.signedChanges(n)...)
+	}
+	code = append(code, `[<`...)
+	code = append(code, a.signedChanges(body)...)
+	code = append(code, bytes.Repeat([]byte(`>-]`), depth)...)
+	return append(code, bytes.Repeat([]byte{'<'}, depth)...)
+}
+
+func (a Assumptions) signedChanges(delta int) Program {
+	if a.Wrapping {
+		return adjust(0, byte(delta))
+	}
+	if delta < 0 {
+		return Program(bytes.Repeat([]byte{'-'}, -delta))
+	}
+	return Program(bytes.Repeat([]byte{'+'}, delta))
+}
+
+// adjust returns the shortest sequence of `+` or `-` that changes from to
+// the value to using wrapping
+func adjust(from, to byte) Program {
+	up := int(to - from)
+	if up <= 128 {
+		return Program(bytes.Repeat([]byte{'+'}, up))
+	}
+	return Program(bytes.Repeat([]byte{'-'}, 256-up))
+}
+
+func adjustLength(from, to byte) int {
+	up := int(to - from)
+	if up <= 128 {
+		return up
+	}
+	return 256 - up
+}
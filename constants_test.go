@@ -0,0 +1,53 @@
+package bf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConstantsTable(t *testing.T) {
+	computed := NewConstants(DefaultAssumptions)
+	for v := 0; v < 256; v++ {
+		if !bytes.Equal(DefaultConstants.Code(byte(v)), computed.Code(byte(v))) {
+			t.Fatalf("constants table is outdated, run go generate")
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	assumptions := []Assumptions{
+		{Start: 0, Cells: 0, Wrapping: true},
+		{Start: 0, Cells: 1, Wrapping: true},
+		{Start: 0, Cells: 2, Wrapping: true},
+		{Start: 0, Cells: 1, Wrapping: false},
+		{Start: 'a', Cells: 1, Wrapping: false},
+		{Start: 200, Cells: 2, Wrapping: true},
+	}
+	for _, a := range assumptions {
+		c := NewConstants(a)
+		for v := 0; v < 256; v++ {
+			code := c.Code(byte(v))
+			if err := calc([]byte{a.Start}, string(code), []byte{byte(v), 0, 0}); err != nil {
+				t.Errorf("%+v 0x%02x = %s", a, v, code)
+			}
+			if len(code) > len(a.changes(a.Start, byte(v))) {
+				t.Errorf("%+v 0x%02x = %s is longer than changes", a, v, code)
+			}
+			if a.Cells == 0 && bytes.ContainsAny(code, "<>[]") {
+				t.Errorf("%+v 0x%02x = %s uses cells", a, v, code)
+			}
+		}
+	}
+}
+
+func TestConstantsShorterThanCompounds(t *testing.T) {
+	if len(DefaultConstants.Code(0x40)) > len(`>++++++++[<++++++++>-]<`) {
+		t.Fail()
+	}
+	two := NewConstants(Assumptions{Cells: 2, Wrapping: true})
+	for v := 0; v < 256; v++ {
+		if len(two.Code(byte(v))) > len(DefaultConstants.Code(byte(v))) {
+			t.Errorf("0x%02x is longer using more cells", v)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package bf
+
+import "bytes"
+
+// Node is an instruction or a loop with its body in a program tree
+type Node struct {
+	Instr byte
+	Body  Tree
+}
+
+// Tree is a sequence of nodes
+type Tree []Node
+
+// ParseTree parses the normalized program into a loop tree, unmatched
+// brackets are dropped like Normalize does
+func ParseTree(program Program) Tree {
+	// the open sequences are collected on a stack and copied to a single
+	// backing array when they are complete
+	nodes := make([]Node, 0, len(program))
+	free := make([]Node, len(program))
+	starts := []int{}
+	for _, b := range program {
+		switch b {
+		case '[':
+			starts = append(starts, len(nodes))
+		case ']':
+			if len(starts) == 0 {
+				continue
+			}
+			start := starts[len(starts)-1]
+			starts = starts[:len(starts)-1]
+			body := sequence(&free, nodes[start:])
+			nodes = append(nodes[:start], Node{Instr: '[', Body: body})
+		default:
+			nodes = append(nodes, Node{Instr: b})
+		}
+	}
+	// the bodies of unclosed loops remain in place
+	return sequence(&free, nodes)
+}
+
+// sequence copies the nodes to the start of the free backing array
+func sequence(free *[]Node, nodes []Node) Tree {
+	n := copy(*free, nodes)
+	t := (*free)[:n:n]
+	*free = (*free)[n:]
+	return t
+}
+
+// Program writes the tree as balanced program
+func (t Tree) Program() Program {
+	buf := bytes.NewBuffer(NewProgram())
+	t.write(buf)
+	return buf.Bytes()
+}
+
+func (t Tree) write(buf *bytes.Buffer) {
+	for _, n := range t {
+		buf.WriteByte(n.Instr)
+		if n.Instr == '[' {
+			n.Body.write(buf)
+			buf.WriteByte(']')
+		}
+	}
+}
+
+// sequences returns the top level sequence and the bodies of all loops
+func (t *Tree) sequences() []*Tree {
+	seqs := []*Tree{t}
+	for i := range *t {
+		if (*t)[i].Instr == '[' {
+			seqs = append(seqs, (*t)[i].Body.sequences()...)
+		}
+	}
+	return seqs
+}
+
+// parents parses the code and picks the tree of a random source entry, the
+// code is crossed with itself when there are no sources. Only the tree of
+// the code is changed by the crossover.
+func parents(code Program, m *Mutation) (Tree, Tree) {
+	a := ParseTree(code)
+	if len(m.Sources) == 0 {
+		return a, a
+	}
+	return a, m.sourceTree(m.Rand.Intn(len(m.Sources)))
+}
+
+// sourceTree parses a source entry once for all mutations using the sources
+func (m *Mutation) sourceTree(n int) Tree {
+	if len(m.trees) != len(m.Sources) {
+		m.trees = make([]Tree, len(m.Sources))
+	}
+	if m.trees[n] == nil {
+		m.trees[n] = ParseTree(m.Sources[n].program)
+	}
+	return m.trees[n]
+}
+
+// randomSequence picks the top level sequence or a loop body
+func randomSequence(t *Tree, m *Mutation) *Tree {
+	seqs := t.sequences()
+	return seqs[m.Rand.Intn(len(seqs))]
+}
+
+// onePointCrossover continues a sequence of the code at a random point with
+// the tail of a sequence of the other parent
+func onePointCrossover(code Program, m *Mutation) Program {
+	a, b := parents(code, m)
+	seqA, seqB := randomSequence(&a, m), randomSequence(&b, m)
+	i, j := m.Rand.Intn(len(*seqA)+1), m.Rand.Intn(len(*seqB)+1)
+	*seqA = append((*seqA)[:i:i], (*seqB)[j:]...)
+	return a.Program()
+}
+
+// twoPointCrossover replaces a range of a sequence of the code with a range
+// of a sequence of the other parent
+func twoPointCrossover(code Program, m *Mutation) Program {
+	a, b := parents(code, m)
+	seqA, seqB := randomSequence(&a, m), randomSequence(&b, m)
+	i, k := randomSpan(len(*seqA), m)
+	j, l := randomSpan(len(*seqB), m)
+	tail := (*seqA)[k:]
+	*seqA = append(append((*seqA)[:i:i], (*seqB)[j:l]...), tail...)
+	return a.Program()
+}
+
+// randomSpan picks a range of at most n nodes
+func randomSpan(n int, m *Mutation) (int, int) {
+	i := m.Rand.Intn(n + 1)
+	return i, i + m.Rand.Intn(n-i+1)
+}
+
+// uniformCrossover aligns a sequence of the code with a sequence of the
+// other parent and takes every node from either parent
+func uniformCrossover(code Program, m *Mutation) Program {
+	a, b := parents(code, m)
+	seqA, seqB := randomSequence(&a, m), randomSequence(&b, m)
+	*seqA = uniformTree(*seqA, *seqB, m)
+	return a.Program()
+}
+
+// uniformTree crosses aligned loops recursively, nodes beyond the end of the
+// shorter sequence are kept with equal chance
+func uniformTree(a, b Tree, m *Mutation) Tree {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	t := Tree{}
+	for i := 0; i < n; i++ {
+		switch {
+		case i >= len(a):
+			if m.Rand.Intn(2) == 0 {
+				t = append(t, b[i])
+			}
+		case i >= len(b):
+			if m.Rand.Intn(2) == 0 {
+				t = append(t, a[i])
+			}
+		case a[i].Instr == '[' && b[i].Instr == '[':
+			t = append(t, Node{Instr: '[', Body: uniformTree(a[i].Body, b[i].Body, m)})
+		case m.Rand.Intn(2) == 0:
+			t = append(t, a[i])
+		default:
+			t = append(t, b[i])
+		}
+	}
+	return t
+}
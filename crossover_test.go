@@ -0,0 +1,67 @@
+package bf
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestParseTree(t *testing.T) {
+	tree := ParseTree(Program(`+[>[-]<-]].`))
+	if len(tree) != 3 || tree[1].Instr != '[' || len(tree[1].Body) != 4 || tree[1].Body[1].Body[0].Instr != '-' {
+		t.Fatalf("unexpected tree %v", tree)
+	}
+	for _, code := range []string{``, `+[>[-]<-].`, `[[]][]`, `+[>[-]<-]].`, `[[+`, `+[-[>]<[`, `]][+[[-]`} {
+		program := Program(code)
+		if got := ParseTree(program).Program(); !bytes.Equal(got, Normalize(program)) {
+			t.Errorf("%q parsed as %q", code, got)
+		}
+	}
+}
+
+func TestCrossoverBalanced(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	operators := map[string]func(Program, *Mutation) Program{
+		"one-point": onePointCrossover,
+		"two-point": twoPointCrossover,
+		"uniform":   uniformCrossover,
+	}
+	for name, crossover := range operators {
+		for i := 0; i < 500; i++ {
+			sources := []Entry{
+				{program: OutputInstructions.RandomProgram(rng, rng.Intn(30))},
+				{program: Program(`>++++[<++++>-]<.`)},
+			}
+			m := &Mutation{Rand: rng, Instructions: OutputInstructions, Sources: sources}
+			code := OutputInstructions.RandomProgram(rng, rng.Intn(30))
+			child := crossover(code, m)
+			if !bytes.Equal(Normalize(child), child) {
+				t.Fatalf("%s produced unbalanced %q", name, child)
+			}
+		}
+	}
+}
+
+func TestCrossoverParents(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := &Mutation{Rand: rng, Instructions: OutputInstructions, Sources: []Entry{{program: Program(`-[-]-`)}}}
+	for i := 0; i < 100; i++ {
+		for _, b := range uniformCrossover(Program(`+[+]+`), m) {
+			if b != '+' && b != '-' && b != '[' && b != ']' {
+				t.Fatalf("unexpected instruction %c", b)
+			}
+		}
+	}
+	if got := twoPointCrossover(Program(`+.`), &Mutation{Rand: rng}); !bytes.Equal(Normalize(got), got) {
+		t.Fatalf("self crossover produced %q", got)
+	}
+	m = &Mutation{Rand: rng, Sources: []Entry{{program: Program(`+[>[-]<]`)}}}
+	for i := 0; i < 100; i++ {
+		onePointCrossover(Program(`-[<[+]]`), m)
+		twoPointCrossover(Program(`-[<[+]]`), m)
+		uniformCrossover(Program(`-[<[+]]`), m)
+	}
+	if got := m.trees[0].Program(); !bytes.Equal(got, Program(`+[>[-]<]`)) {
+		t.Fatalf("source tree changed to %q", got)
+	}
+}
@@ -0,0 +1,240 @@
+package bf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dialectInstructions is the order of the tokens of a dialect
+const dialectInstructions = `><+-.,[]`
+
+// Dialect substitutes the instructions with tokens, a token consists of one
+// or more words separated by whitespace and doesn't match inside a word
+type Dialect struct {
+	Name string
+	// Tokens for the instructions in the order ><+-.,[]
+	Tokens [8]string
+	// Separator is written between tokens when translating
+	Separator string
+	tokens    [][]byte
+	lookahead int
+}
+
+// NewDialect constructs a trivial substitution of the instructions
+func NewDialect(name string, tokens [8]string, separator string) *Dialect {
+	d := &Dialect{Name: name, Tokens: tokens, Separator: separator}
+	for _, token := range tokens {
+		// whitespace in the input is matched as a single space
+		t := []byte(strings.Join(strings.Fields(token), " "))
+		d.tokens = append(d.tokens, t)
+		if len(t) > d.lookahead {
+			d.lookahead = len(t)
+		}
+	}
+	return d
+}
+
+// Built in dialects
+var (
+	BrainfuckDialect = NewDialect("bf", [8]string{">", "<", "+", "-", ".", ",", "[", "]"}, "")
+	OokDialect       = NewDialect("ook", [8]string{
+		"Ook. Ook?", "Ook? Ook.", "Ook. Ook.", "Ook! Ook!",
+		"Ook! Ook.", "Ook. Ook!", "Ook! Ook?", "Ook? Ook!",
+	}, " ")
+	BlubDialect = NewDialect("blub", [8]string{
+		"Blub. Blub?", "Blub? Blub.", "Blub. Blub.", "Blub! Blub!",
+		"Blub! Blub.", "Blub. Blub!", "Blub! Blub?", "Blub? Blub!",
+	}, " ")
+)
+
+var dialects = []*Dialect{BrainfuckDialect, OokDialect, BlubDialect}
+
+// DialectNames returns the names of the built in dialects
+func DialectNames() []string {
+	names := []string{}
+	for _, d := range dialects {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// DialectByName returns the built in dialect with the name
+func DialectByName(name string) (*Dialect, error) {
+	for _, d := range dialects {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown dialect %q (choose from %s)", name, strings.Join(DialectNames(), ", "))
+}
+
+func isWord(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_'
+}
+
+// match returns the instruction of the longest token at the start of the
+// input after prev and the length of the token, tokens that start or end
+// with a letter or digit must not continue a word
+func (d *Dialect) match(prev byte, input []byte) (byte, int) {
+	instr, length := byte(0), 0
+	for n, t := range d.tokens {
+		if len(t) <= length || !bytes.HasPrefix(input, t) {
+			continue
+		}
+		if isWord(t[0]) && isWord(prev) || isWord(t[len(t)-1]) && len(input) > len(t) && isWord(input[len(t)]) {
+			continue
+		}
+		instr, length = dialectInstructions[n], len(t)
+	}
+	return instr, length
+}
+
+// Reader returns a reader of the instructions of the program in the
+// dialect, text that doesn't match a token is skipped as comment
+func (d *Dialect) Reader(r io.Reader) io.Reader {
+	return &lexer{d: d, r: bufio.NewReader(r)}
+}
+
+// SeparatedReader is a Reader that passes the first `!` that isn't part of
+// a token, the text after it is read unchanged so that it can be used as
+// input with InputSeparator
+func (d *Dialect) SeparatedReader(r io.Reader) io.Reader {
+	return &lexer{d: d, r: bufio.NewReader(r), separator: true}
+}
+
+// lexer reads instructions from a program in a dialect
+type lexer struct {
+	d   *Dialect
+	r   *bufio.Reader
+	buf []byte // input with whitespace collapsed into single spaces
+	// prev is the byte before buf
+	prev byte
+	eof  bool
+	// separator passes the first ! that isn't part of a token
+	separator bool
+	separated bool
+	raw       []byte // unchanged input of buf
+	offsets   []int  // positions in raw of the bytes of buf
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+// fill reads input until the buffer contains enough input to match the
+// longest token
+func (l *lexer) fill() error {
+	for len(l.buf) <= l.d.lookahead && !l.eof {
+		b, err := l.r.ReadByte()
+		if err == io.EOF {
+			l.eof = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if l.separator {
+			l.raw = append(l.raw, b)
+		}
+		if isSpace(b) {
+			if len(l.buf) > 0 && l.buf[len(l.buf)-1] == ' ' {
+				continue
+			}
+			b = ' '
+		}
+		l.buf = append(l.buf, b)
+		if l.separator {
+			l.offsets = append(l.offsets, len(l.raw)-1)
+		}
+	}
+	return nil
+}
+
+// skip removes n bytes from the buffer
+func (l *lexer) skip(n int) {
+	l.prev = l.buf[n-1]
+	l.buf = l.buf[n:]
+	if !l.separator {
+		return
+	}
+	l.offsets = l.offsets[n:]
+	drop := len(l.raw)
+	if len(l.offsets) > 0 {
+		drop = l.offsets[0]
+	}
+	l.raw = l.raw[drop:]
+	for k := range l.offsets {
+		l.offsets[k] -= drop
+	}
+}
+
+func (l *lexer) Read(p []byte) (int, error) {
+	if l.separated {
+		// the text after the separator
+		if len(l.raw) > 0 {
+			n := copy(p, l.raw)
+			l.raw = l.raw[n:]
+			return n, nil
+		}
+		return l.r.Read(p)
+	}
+	n := 0
+	for n < len(p) {
+		if err := l.fill(); err != nil {
+			return n, err
+		}
+		if len(l.buf) == 0 {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, io.EOF
+		}
+		if l.buf[0] == ' ' {
+			l.skip(1)
+			continue
+		}
+		instr, length := l.d.match(l.prev, l.buf)
+		if length == 0 && l.separator && l.buf[0] == '!' {
+			p[n] = '!'
+			l.separated = true
+			l.raw = l.raw[l.offsets[0]+1:]
+			l.buf, l.offsets = nil, nil
+			return n + 1, nil
+		}
+		if length == 0 {
+			// comment
+			l.skip(1)
+			continue
+		}
+		p[n] = instr
+		n++
+		l.skip(length)
+	}
+	return n, nil
+}
+
+// Translate writes the program in the from dialect as program in the to
+// dialect, comments are dropped
+func Translate(w io.Writer, r io.Reader, from, to *Dialect) error {
+	bw := bufio.NewWriter(w)
+	input := bufio.NewReader(from.Reader(r))
+	first := true
+	for {
+		b, err := input.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !first {
+			bw.WriteString(to.Separator)
+		}
+		first = false
+		bw.WriteString(to.Tokens[strings.IndexByte(dialectInstructions, b)])
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,121 @@
+package bf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestOok(t *testing.T) {
+	out := &strings.Builder{}
+	i := NewInterpreter(out, nil)
+	program := "Ook. Ook. comment Ook! Ook.\n\tOok.   Ook.\nOok!\nOok."
+	if err := i.Interpret(OokDialect.Reader(strings.NewReader(program))); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\x01\x02" {
+		t.Fatalf("printed %q", out.String())
+	}
+}
+
+func TestDialectSeparator(t *testing.T) {
+	// , and . followed by the input, the ! of the tokens is not the separator
+	tests := []struct {
+		d        *Dialect
+		program  string
+		expected string
+	}{
+		{OokDialect, "Ook. Ook! Ook! Ook.\n!hi\n", "h"},
+		// whitespace after the separator is input
+		{BlubDialect, "Blub. Blub!\tBlub! Blub. ! hi", " "},
+	}
+	for _, test := range tests {
+		out := &strings.Builder{}
+		i := NewInterpreter(out, nil)
+		i.InputSeparator = true
+		if err := i.Interpret(test.d.SeparatedReader(strings.NewReader(test.program))); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%s printed %q", test.d.Name, out.String())
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	hello, err := ioutil.ReadFile("examples/hello.bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := Normalize(commands(hello))
+	for _, d := range dialects {
+		translated := &bytes.Buffer{}
+		if err := Translate(translated, bytes.NewReader(hello), BrainfuckDialect, d); err != nil {
+			t.Fatal(err)
+		}
+		out := &strings.Builder{}
+		i := NewInterpreter(out, nil)
+		if err := i.Interpret(d.Reader(bytes.NewReader(translated.Bytes()))); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "Hello World!\n" {
+			t.Errorf("%s printed %q", d.Name, out.String())
+		}
+		back := &bytes.Buffer{}
+		if err := Translate(back, translated, d, BrainfuckDialect); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(back.Bytes(), commands) {
+			t.Errorf("%s translated back as %q", d.Name, back.String())
+		}
+	}
+}
+
+func TestTrivialSubstitution(t *testing.T) {
+	// tokens that are prefixes of other tokens
+	d := NewDialect("pika", [8]string{"pipi", "pichu", "pi", "ka", "pikachu", "pikapi", "pika", "chu"}, " ")
+	out := &strings.Builder{}
+	i := NewInterpreter(out, nil)
+	if err := i.Interpret(d.Reader(strings.NewReader("pi pi pika pikachu pipi pi ka chu pikachu"))); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\x02\x00" {
+		t.Fatalf("printed %q", out.String())
+	}
+	if _, err := DialectByName("unknown"); err == nil {
+		t.Fail()
+	}
+}
+
+func TestDialectWordBoundaries(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"Look. Ook. Ook. Ook! Ook.", "\x01"},
+		{"Ook. Ook. Ook! Ook.", "\x01"},
+		{"Ook. Ook.Ook! Ook.", "\x01"},
+		{"Ook. Ooks. Ook! Ook.", "\x00"},
+		{"Ook. Ook. BookOok! Ook.", ""},
+	}
+	for _, test := range tests {
+		out := &strings.Builder{}
+		i := NewInterpreter(out, nil)
+		if err := i.Interpret(OokDialect.Reader(strings.NewReader(test.program))); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%q printed %q", test.program, out.String())
+		}
+	}
+	d := NewDialect("words", [8]string{"right", "left", "up", "down", "out", "in", "loop", "pool"}, " ")
+	out := &strings.Builder{}
+	i := NewInterpreter(out, nil)
+	if err := i.Interpret(d.Reader(strings.NewReader("up setup up, upup out"))); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\x02" {
+		t.Errorf("printed %q", out.String())
+	}
+}
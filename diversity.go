@@ -0,0 +1,285 @@
+package bf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Diversity selects how the kept entries are prevented from becoming clones
+type Diversity string
+
+// Diversity mechanisms
+const (
+	// NoDiversity keeps the fittest entries
+	NoDiversity Diversity = ""
+	// FitnessSharing divides the fitness by the number of similar programs
+	FitnessSharing Diversity = "sharing"
+	// Crowding only lets mutated children replace their own parent
+	Crowding Diversity = "crowding"
+	// Novelty rewards outputs that differ from the population and the archive
+	Novelty Diversity = "novelty"
+)
+
+var diversities = []Diversity{FitnessSharing, Crowding, Novelty}
+
+// DiversityNames returns the names of the diversity mechanisms
+func DiversityNames() []string {
+	names := []string{"none"}
+	for _, d := range diversities {
+		names = append(names, string(d))
+	}
+	return names
+}
+
+// DiversityByName returns the diversity mechanism, none disables it
+func DiversityByName(name string) (Diversity, error) {
+	if name == "" || name == "none" {
+		return NoDiversity, nil
+	}
+	for _, d := range diversities {
+		if string(d) == name {
+			return d, nil
+		}
+	}
+	return NoDiversity, fmt.Errorf("Unknown diversity %q (choose from %s)", name, strings.Join(DiversityNames(), ", "))
+}
+
+// sharingRadius is the edit distance at which programs no longer share fitness
+const sharingRadius = 8
+
+// sharingCandidates is the number of fittest entries that share fitness
+const sharingCandidates = 4 * keepSize
+
+// noveltyNeighbours is the number of nearest outputs that determine novelty
+const noveltyNeighbours = 15
+
+// noveltyArchiveSize limits the archive of novel outputs
+const noveltyArchiveSize = 256
+
+// distanceBound limits the edit distance reported in the stats
+const distanceBound = 32
+
+// selectEntries moves the entries that are kept to the front
+func (p *Population) selectEntries() {
+	switch {
+	case p.MultiObjective:
+		nonDominatedSort(p.entries)
+	case p.Diversity == FitnessSharing:
+		sort.Stable(byFitness(p.entries))
+		p.shareFitness()
+	case p.Diversity == Crowding:
+		p.crowd()
+	case p.Diversity == Novelty:
+		p.rewardNovelty()
+	default:
+		sort.Stable(byFitness(p.entries))
+	}
+}
+
+// shareFitness divides the fitness of the candidates by their niche count,
+// the fittest entry is always kept
+func (p *Population) shareFitness() {
+	candidates := p.entries[:sharingCandidates]
+	niches := make([]float64, len(candidates))
+	for i := range candidates {
+		niches[i]++
+		for j := i + 1; j < len(candidates); j++ {
+			d := boundedDistance(candidates[i].program, candidates[j].program, sharingRadius)
+			share := 1 - float64(d)/sharingRadius
+			niches[i] += share
+			niches[j] += share
+		}
+	}
+	for i := range candidates {
+		candidates[i].score = sharedFitness(candidates[i].fitness, niches[i])
+	}
+	sort.Stable(byScore(candidates[1:]))
+}
+
+// sharedFitness lowers the fitness also when it is negative
+func sharedFitness(fitness, niche float64) float64 {
+	if fitness < 0 {
+		return fitness * niche
+	}
+	return fitness / niche
+}
+
+// crowd pairs every child with its own parent (deterministic crowding), a
+// child replaces its parent when it is at least as fit, the random programs
+// of the new generation have no parent and are never kept
+func (p *Population) crowd() {
+	for i := 0; i < keepSize; i++ {
+		// m == 1 is the new generation
+		for m := 2; m < manipulationSize; m++ {
+			child := m*keepSize + i
+			if p.entries[child].fitness >= p.entries[i].fitness {
+				p.entries[i], p.entries[child] = p.entries[child], p.entries[i]
+			}
+		}
+	}
+}
+
+// rewardNovelty adds the novelty of the output to the fitness and archives
+// the most novel output, the fittest entry is always kept
+func (p *Population) rewardNovelty() {
+	unique := map[string]bool{}
+	outputs := [][]byte{}
+	for _, e := range p.entries {
+		if !unique[string(e.output)] {
+			unique[string(e.output)] = true
+			outputs = append(outputs, e.output)
+		}
+	}
+	others := append(append([][]byte{}, outputs...), p.archive...)
+	novelty := map[string]float64{}
+	var mostNovel []byte
+	for _, output := range outputs {
+		n := outputNovelty(output, others)
+		novelty[string(output)] = n
+		if mostNovel == nil || n > novelty[string(mostNovel)] {
+			mostNovel = output
+		}
+	}
+	fittest := 0
+	for i := range p.entries {
+		p.entries[i].score = p.entries[i].fitness + novelty[string(p.entries[i].output)]
+		if p.entries[i].fitness > p.entries[fittest].fitness {
+			fittest = i
+		}
+	}
+	p.entries[0], p.entries[fittest] = p.entries[fittest], p.entries[0]
+	sort.Stable(byScore(p.entries[1:]))
+	p.archiveOutput(mostNovel)
+}
+
+// outputNovelty is the mean normalized distance to the nearest other outputs
+func outputNovelty(output []byte, others [][]byte) float64 {
+	distances := []float64{}
+	skipped := false
+	for _, other := range others {
+		if !skipped && string(other) == string(output) {
+			// the output itself
+			skipped = true
+			continue
+		}
+		distances = append(distances, outputDistance(output, other))
+	}
+	if len(distances) == 0 {
+		return 0
+	}
+	sort.Float64s(distances)
+	if len(distances) > noveltyNeighbours {
+		distances = distances[:noveltyNeighbours]
+	}
+	sum := 0.0
+	for _, d := range distances {
+		sum += d
+	}
+	return sum / float64(len(distances))
+}
+
+// outputDistance is the ratio of differing positions
+func outputDistance(a, b []byte) float64 {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(hammingDistance(a, b)) / float64(n)
+}
+
+func (p *Population) archiveOutput(output []byte) {
+	if output == nil {
+		return
+	}
+	for _, archived := range p.archive {
+		if string(archived) == string(output) {
+			return
+		}
+	}
+	p.archive = append(p.archive, append([]byte{}, output...))
+	if len(p.archive) > noveltyArchiveSize {
+		p.archive = p.archive[len(p.archive)-noveltyArchiveSize:]
+	}
+}
+
+// boundedDistance is the edit distance between the programs, distances of
+// at least bound are reported as bound
+func boundedDistance(a, b []byte, bound int) int {
+	if abs(len(a)-len(b)) >= bound {
+		return bound
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+		if j > bound {
+			prev[j] = bound
+		}
+	}
+	// only the band of positions within bound of the diagonal is computed
+	for i := 1; i <= len(a); i++ {
+		lo, hi := i-bound, i+bound
+		if lo < 1 {
+			lo = 1
+			curr[0] = i
+		} else {
+			curr[lo-1] = bound
+		}
+		if hi > len(b) {
+			hi = len(b)
+		}
+		rowMin := curr[lo-1]
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if d > bound {
+				d = bound
+			}
+			curr[j] = d
+			if d < rowMin {
+				rowMin = d
+			}
+		}
+		if hi < len(b) {
+			curr[hi+1] = bound
+		}
+		if rowMin >= bound {
+			return bound
+		}
+		prev, curr = curr, prev
+	}
+	if prev[len(b)] > bound {
+		return bound
+	}
+	return prev[len(b)]
+}
+
+// meanDistance estimates the mean bounded edit distance between the
+// programs, every program of the first half is paired with one of the second
+// half so that the stats don't slow down the generations
+func meanDistance(entries []Entry) float64 {
+	half := len(entries) / 2
+	if half == 0 {
+		return 0
+	}
+	sum := 0
+	for i := 0; i < half; i++ {
+		sum += boundedDistance(entries[i].program, entries[half+i].program, distanceBound)
+	}
+	return float64(sum) / float64(half)
+}
+
+type byScore []Entry
+
+func (e byScore) Len() int      { return len(e) }
+func (e byScore) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e byScore) Less(i, j int) bool {
+	return e[i].score > e[j].score
+}
@@ -0,0 +1,131 @@
+package bf
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBoundedDistance(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := OutputInstructions.RandomProgram(rng, rng.Intn(20))
+		b := NewProgramClone(a)
+		for n := rng.Intn(10); n > 0 && len(b) > 0; n-- {
+			pos := rng.Intn(len(b))
+			switch rng.Intn(3) {
+			case 0:
+				b[pos] = OutputInstructions.Random(rng)
+			case 1:
+				b = removeAt(b, pos, 1)
+			default:
+				b = insertAt(b, pos, Program{OutputInstructions.Random(rng)})
+			}
+		}
+		bound := 1 + rng.Intn(8)
+		expected := levenshteinDistance(a, b)
+		if expected > bound {
+			expected = bound
+		}
+		if d := boundedDistance(a, b, bound); d != expected {
+			t.Fatalf("distance %q %q bound %d = %d expected %d", a, b, bound, d, expected)
+		}
+	}
+}
+
+func TestDiversityByName(t *testing.T) {
+	for _, name := range DiversityNames() {
+		if _, err := DiversityByName(name); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := DiversityByName("unknown"); err == nil {
+		t.Fail()
+	}
+}
+
+func TestCrowding(t *testing.T) {
+	p := NewPopulation()
+	for i := range p.entries {
+		p.entries[i].fitness = float64(i % keepSize)
+	}
+	p.entries[2*keepSize+1].fitness = 100
+	// the new generation has no parent to replace
+	p.entries[keepSize+2].fitness = 100
+	p.crowd()
+	for i := 0; i < keepSize; i++ {
+		expected := float64(i)
+		if i == 1 {
+			expected = 100
+		}
+		if p.entries[i].fitness != expected {
+			t.Fatalf("family %d kept fitness %f", i, p.entries[i].fitness)
+		}
+	}
+}
+
+func TestFitnessSharing(t *testing.T) {
+	p := NewPopulation()
+	for i := range p.entries {
+		p.entries[i] = Entry{program: Program(`+++.`)}
+		if i < 100 {
+			p.entries[i].fitness = 2
+		}
+	}
+	p.entries[populationSize-1] = Entry{program: Program(`>>>>>>>>>>-.`), fitness: 1}
+	p.entries[0].fitness = 3
+	p.selectEntries()
+	if p.entries[0].fitness != 3 {
+		t.Fatal("fittest not kept")
+	}
+	p.Diversity = FitnessSharing
+	p.entries[0].fitness = 3
+	p.selectEntries()
+	if p.entries[0].fitness != 3 || p.entries[1].fitness != 1 {
+		t.Fatalf("unique program not preferred %v", p.entries[1].String())
+	}
+}
+
+func TestDiversityPopulation(t *testing.T) {
+	for _, d := range diversities {
+		p := seededPopulation()
+		p.Diversity = d
+		for i := 0; i < 30 && !p.Fittest().success; i++ {
+			p.EvaluateAndMutate()
+		}
+		if !p.Fittest().success {
+			t.Errorf("%s: no success %s", d, p.Fittest().String())
+		}
+		if p.Stats().Distance <= 0 {
+			t.Errorf("%s: no distance between kept programs", d)
+		}
+	}
+}
+
+func TestNoveltyResume(t *testing.T) {
+	p := seededPopulation()
+	p.Diversity = Novelty
+	for i := 0; i < 5; i++ {
+		p.EvaluateAndMutate()
+	}
+	if len(p.archive) != 5 {
+		t.Fatalf("archive has %d outputs", len(p.archive))
+	}
+	buf := &bytes.Buffer{}
+	if err := p.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := LoadPopulation(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		p.EvaluateAndMutate()
+		resumed.EvaluateAndMutate()
+	}
+	for i := range p.entries {
+		if !bytes.Equal(resumed.entries[i].program, p.entries[i].program) {
+			t.Fatalf("entry %d differs", i)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Extension selects a dialect on top of the core instructions
+type Extension int
+
+// Extensions of the core instructions
+const (
+	NoExtension Extension = iota
+	// ExtendedTypeI adds a storage register, bitwise operations and
+	// initializes memory from the data behind the `@` that ends the program
+	ExtendedTypeI
+	// ExtendedTypeII places code and data in the same memory, so that `?`
+	// can jump to the pointer and programs can modify themselves
+	ExtendedTypeII
+	// ExtendedTypeIII adds arithmetic with the storage register and hex
+	// digits that set the cell
+	ExtendedTypeIII
+	// Brainfork adds `Y` that forks the current thread
+	Brainfork
+	// PBrain adds procedures that are defined using `(` and `)` and called
+	// using `:`
+	PBrain
+)
+
+var extensionNames = map[Extension]string{
+	NoExtension:     "none",
+	ExtendedTypeI:   "type1",
+	ExtendedTypeII:  "type2",
+	ExtendedTypeIII: "type3",
+	Brainfork:       "brainfork",
+	PBrain:          "pbrain",
+}
+
+func (e Extension) String() string {
+	return extensionNames[e]
+}
+
+// ExtensionNames returns the names of the extensions
+func ExtensionNames() []string {
+	names := []string{}
+	for e := NoExtension; int(e) < len(extensionNames); e++ {
+		names = append(names, e.String())
+	}
+	return names
+}
+
+// ExtensionByName returns the extension with the name
+func ExtensionByName(name string) (Extension, error) {
+	for e, n := range extensionNames {
+		if n == name {
+			return e, nil
+		}
+	}
+	return NoExtension, fmt.Errorf("Unknown extension %q (choose from %s)", name, strings.Join(ExtensionNames(), ", "))
+}
+
+// extendedTypeI is true when the type I instructions are available, the
+// later types include the earlier ones
+func (e Extension) extendedTypeI() bool {
+	return e == ExtendedTypeI || e.sharedMemory()
+}
+
+// instructions of the extensions in addition to the core instructions, the
+// later extended types include the earlier ones
+var extensionInstructions = map[Extension]string{
+	ExtendedTypeI:   "@$!}{~^&|",
+	ExtendedTypeII:  "@$!}{~^&|?",
+	ExtendedTypeIII: "@$!}{~^&|?=_*/%0123456789ABCDEF",
+	Brainfork:       "Y",
+	PBrain:          "():",
+}
+
+// instructionTables has a table per extension of the bytes that are
+// instructions, the other bytes are comments
+var instructionTables = map[Extension]*[256]bool{}
+
+func init() {
+	for e := range extensionNames {
+		table := &[256]bool{}
+		for _, b := range []byte(extensionInstructions[e]) {
+			table[b] = true
+		}
+		instructionTables[e] = table
+	}
+}
+
+// sharedMemory is true when code and data share the memory
+func (e Extension) sharedMemory() bool {
+	return e == ExtendedTypeII || e == ExtendedTypeIII
+}
+
+// prefetch reads the code up to `@` into code memory, the data that
+// follows initializes the memory from the pointer onwards. When the memory
+// is shared the code is followed by the data and the pointer starts at the
+// data.
+func (i *Interpreter) prefetch(r io.Reader) error {
+	found, err := i.readCode(r, '@')
+	if err != nil {
+		return err
+	}
+	if found {
+		i.code = append(i.code, '@')
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if i.Extension.sharedMemory() {
+		i.ptr = len(i.code)
+		i.memory = append(append(i.code, data...), memory()...)
+		i.code = i.memory
+		return nil
+	}
+	for len(i.memory) < i.ptr+len(data) {
+		i.memory = append(i.memory, memory()...)
+	}
+	copy(i.memory[i.ptr:], data)
+	return nil
+}
+
+// extended executes the instructions of the extension, unknown instructions
+// are comments
+func (i *Interpreter) extended(code byte, r io.Reader, strict bool) error {
+	if i.Extension == PBrain {
+		return i.procedure(code, r, strict)
+	}
+	if i.Extension == Brainfork {
+		if code == 'Y' {
+			i.fork()
+		}
+		return nil
+	}
+	if !i.Extension.extendedTypeI() {
+		return nil
+	}
+	cell := &i.memory[i.ptr]
+	switch code {
+	case '$':
+		i.storage = *cell
+	case '!':
+		*cell = i.storage
+	case '}':
+		*cell >>= 1
+	case '{':
+		*cell <<= 1
+	case '~':
+		*cell = ^*cell
+	case '^':
+		*cell ^= i.storage
+	case '&':
+		*cell &= i.storage
+	case '|':
+		*cell |= i.storage
+	case '?':
+		if i.Extension.sharedMemory() {
+			i.ip = i.ptr
+		}
+	default:
+		if i.Extension == ExtendedTypeIII {
+			return i.extendedTypeIII(code)
+		}
+	}
+	return nil
+}
+
+// extendedTypeIII executes arithmetic with the storage register and the
+// hex digits that set the cell to sixteen times the digit
+func (i *Interpreter) extendedTypeIII(code byte) error {
+	cell := &i.memory[i.ptr]
+	switch {
+	case code == '=':
+		*cell += i.storage
+	case code == '_':
+		*cell -= i.storage
+	case code == '*':
+		*cell *= i.storage
+	case code == '/' || code == '%':
+		if i.storage == 0 {
+			return ErrDivisionByZero
+		}
+		if code == '/' {
+			*cell /= i.storage
+		} else {
+			*cell %= i.storage
+		}
+	case code >= '0' && code <= '9':
+		*cell = (code - '0') * 16
+	case code >= 'A' && code <= 'F':
+		*cell = (code - 'A' + 10) * 16
+	}
+	return nil
+}
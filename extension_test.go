@@ -0,0 +1,140 @@
+package bf
+
+import (
+	"strings"
+	"testing"
+)
+
+func extended(t *testing.T, e Extension, program string, input string) string {
+	out := &strings.Builder{}
+	i := NewInterpreter(out, strings.NewReader(input))
+	i.Extension = e
+	if err := i.Interpret(code(program)); err != nil {
+		t.Fatalf("%s: %v", program, err)
+	}
+	return out.String()
+}
+
+func TestExtendedTypeI(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{".>.>.@ABC", "ABC"},
+		{"[.>]@Hello", "Hello"},
+		{"+++$>!.", "\x03"},
+		{"$>^.@\x0f\xf0", "\xff"},
+		{"$>&.@\x0f\xf0", "\x00"},
+		{"$>|.@\x0f\xf0", "\xff"},
+		{"}.{{.@\x08", "\x04\x10"},
+		{"~.", "\xff"},
+		{"{.@\x81", "\x02"},
+		{"+.@+.", ","}, // data is not executed
+	}
+	for _, test := range tests {
+		if out := extended(t, ExtendedTypeI, test.program, ""); out != test.expected {
+			t.Errorf("%q printed %q expected %q", test.program, out, test.expected)
+		}
+	}
+	// extended instructions are comments without the extension
+	if out := extended(t, NoExtension, "+@$~+.", ""); out != "\x02" {
+		t.Errorf("printed %q", out)
+	}
+}
+
+func TestExtendedTypeII(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		// the pointer starts at the data behind the code
+		{".@A", "A"},
+		{"<.@", "@"},
+		// jump to the data and execute it as code
+		{"?@.", "."},
+		// change the @ that ends the program into a .
+		{"<" + strings.Repeat("-", '@'-'.') + "@", "."},
+		{"+$>!.@", "\x01"},
+	}
+	for _, test := range tests {
+		if out := extended(t, ExtendedTypeII, test.program, ""); out != test.expected {
+			t.Errorf("%q printed %q expected %q", test.program, out, test.expected)
+		}
+	}
+	if out := extended(t, ExtendedTypeI, "?@.", ""); out != "" {
+		t.Errorf("type I jumped %q", out)
+	}
+}
+
+func TestExtendedTypeIII(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"4+.", "A"},
+		{"F.0.", "\xf0\x00"},
+		{"2$4=.", "`"},
+		{"2$4_.", " "},
+		{"+++$++*.", "\x0f"},
+		{"+++$F/.", "P"},
+		{"+++$++++%.", "\x01"},
+		{"4+.@B", "A"},
+	}
+	for _, test := range tests {
+		if out := extended(t, ExtendedTypeIII, test.program, ""); out != test.expected {
+			t.Errorf("%q printed %q expected %q", test.program, out, test.expected)
+		}
+	}
+	if out := extended(t, ExtendedTypeII, "4+.", ""); out != "\x01" {
+		t.Errorf("type II used hex digit %q", out)
+	}
+	i := NewInterpreter(nil, nil)
+	i.Extension = ExtendedTypeIII
+	if err := i.Interpret(code("+/")); err != ErrDivisionByZero {
+		t.Fatal(err)
+	}
+}
+
+func TestExtendedInstructions(t *testing.T) {
+	// every instruction of the extended types with hand computed results
+	tests := []struct {
+		extension Extension
+		program   string
+		input     string
+		expected  string
+	}{
+		// the data behind @ initializes the memory
+		{ExtendedTypeI, "[.>]@Hello World!", "", "Hello World!"},
+		// copy a cell using the storage
+		{ExtendedTypeI, ",$>!.", "x", "x"},
+		// self-modification: the , in front of @ is changed into a .
+		{ExtendedTypeII, "<<++,@", "", "."},
+		// code written into the data is executed after jumping to it
+		{ExtendedTypeII, "+++++++[>+++++++<-]>---?", "", "."},
+		// hex digits set the cell to sixteen times their value
+		{ExtendedTypeIII, "4++++++++.6+++++++++.", "", "Hi"},
+		// arithmetic with the storage
+		{ExtendedTypeIII, "2$3=.", "", "P"},
+		{ExtendedTypeIII, "2$7_.", "", "P"},
+		{ExtendedTypeIII, "++$3*.", "", "`"},
+		{ExtendedTypeIII, "++$8/.", "", "@"},
+		{ExtendedTypeIII, "3$A%.", "", "\x10"},
+	}
+	for _, test := range tests {
+		if out := extended(t, test.extension, test.program, test.input); out != test.expected {
+			t.Errorf("%s %q printed %q expected %q", test.extension, test.program, out, test.expected)
+		}
+	}
+}
+
+func TestExtensionByName(t *testing.T) {
+	for _, name := range ExtensionNames() {
+		e, err := ExtensionByName(name)
+		if err != nil || e.String() != name {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, err := ExtensionByName("unknown"); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,259 @@
+package bf
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fitness scores the outcome of running a program for a test case,
+// higher scores are better
+type Fitness interface {
+	Fitness(program Program, output []byte, runtime int, err error, c TestCase) float64
+}
+
+// FitnessFunc adapts a function to the Fitness interface
+type FitnessFunc func(program Program, output []byte, runtime int, err error, c TestCase) float64
+
+// Fitness calls the function
+func (f FitnessFunc) Fitness(program Program, output []byte, runtime int, err error, c TestCase) float64 {
+	return f(program, output, runtime, err, c)
+}
+
+// Weighted is a fitness function with a weight
+type Weighted struct {
+	Fitness Fitness
+	Weight  float64
+}
+
+// Combined sums the weighted scores of multiple fitness functions
+type Combined []Weighted
+
+// Fitness returns the weighted sum
+func (c Combined) Fitness(program Program, output []byte, runtime int, err error, tc TestCase) float64 {
+	fitness := 0.0
+	for _, w := range c {
+		fitness += w.Weight * w.Fitness.Fitness(program, output, runtime, err, tc)
+	}
+	return fitness
+}
+
+// DefaultFitness values early matching characters higher and optimizes for
+// program length once the output matches
+var DefaultFitness Fitness = FitnessFunc(defaultFitness)
+
+// HammingFitness counts the characters that match at the same position
+var HammingFitness Fitness = FitnessFunc(hammingFitness)
+
+// LevenshteinFitness uses the edit distance between output and expected
+var LevenshteinFitness Fitness = FitnessFunc(levenshteinFitness)
+
+// PrefixFitness uses the length of the longest common prefix
+var PrefixFitness Fitness = FitnessFunc(prefixFitness)
+
+// BitFitness counts the bits that match at the same position
+var BitFitness Fitness = FitnessFunc(bitFitness)
+
+var fitnessFunctions = map[string]Fitness{
+	"default":     DefaultFitness,
+	"hamming":     HammingFitness,
+	"levenshtein": LevenshteinFitness,
+	"prefix":      PrefixFitness,
+	"bits":        BitFitness,
+}
+
+// FitnessNames lists the names of the built-in fitness functions
+func FitnessNames() []string {
+	names := make([]string, 0, len(fitnessFunctions))
+	for name := range fitnessFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FitnessByName returns a built-in fitness function, multiple functions are
+// combined using a comma separated list of name:weight pairs
+// (for example "prefix:1,levenshtein:0.5")
+func FitnessByName(spec string) (Fitness, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) == 1 && !strings.Contains(spec, ":") {
+		return fitnessByName(spec)
+	}
+	combined := Combined{}
+	for _, part := range parts {
+		name := part
+		weight := 1.0
+		if pos := strings.Index(part, ":"); pos >= 0 {
+			name = part[:pos]
+			w, err := strconv.ParseFloat(part[pos+1:], 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid fitness weight %q", part)
+			}
+			weight = w
+		}
+		f, err := fitnessByName(name)
+		if err != nil {
+			return nil, err
+		}
+		combined = append(combined, Weighted{Fitness: f, Weight: weight})
+	}
+	return combined, nil
+}
+
+func fitnessByName(name string) (Fitness, error) {
+	f, ok := fitnessFunctions[strings.TrimSpace(name)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown fitness %q (choose from %s)", name, strings.Join(FitnessNames(), ", "))
+	}
+	return f, nil
+}
+
+func characterFitness(expected, actual byte) float64 {
+	diff := math.Abs(float64(expected)-float64(actual)) / 255.0
+	return 1.0 - diff
+}
+
+func defaultFitness(program Program, output []byte, runtime int, err error, c TestCase) float64 {
+	if err != nil {
+		return 0
+	}
+	expected := c.Expected
+	fitness := 0.0
+	factor := 1.0
+	ok := true
+	for i, ch := range expected {
+		if i < len(output) {
+			ok = ok && ch == output[i]
+			if ok {
+				fitness += 1.0
+			} else {
+				fitness += (characterFitness(ch, output[i]) / factor)
+				factor *= 10
+			}
+		} else {
+			ok = false
+		}
+	}
+
+	lenOutput := float64(len(output))
+	lenExpected := float64(len(expected))
+	if lenExpected == 0 {
+		// every extra character counts as a wrong character
+		fitness -= lenOutput
+	} else if lenOutput > lenExpected {
+		fitness += (1.0 - (lenOutput / lenExpected))
+	}
+
+	if fitness > 0 {
+		factor *= 10
+		weight := 1.0 / float64(len(program)+1)
+		fitness += (weight / factor)
+	}
+	return fitness
+}
+
+// distanceFitness turns a distance into a score relative to the expected
+// length, shorter programs are preferred when the distance is zero
+func distanceFitness(program Program, expected int, distance float64) float64 {
+	fitness := float64(expected) - distance
+	if distance == 0 {
+		fitness += 1.0 / float64(len(program)+1)
+	}
+	return fitness
+}
+
+func hammingFitness(program Program, output []byte, runtime int, err error, c TestCase) float64 {
+	if err != nil {
+		return 0
+	}
+	return distanceFitness(program, len(c.Expected), float64(hammingDistance(output, c.Expected)))
+}
+
+func levenshteinFitness(program Program, output []byte, runtime int, err error, c TestCase) float64 {
+	if err != nil {
+		return 0
+	}
+	return distanceFitness(program, len(c.Expected), float64(levenshteinDistance(output, c.Expected)))
+}
+
+func prefixFitness(program Program, output []byte, runtime int, err error, c TestCase) float64 {
+	if err != nil {
+		return 0
+	}
+	return distanceFitness(program, len(c.Expected), float64(prefixDistance(output, c.Expected)))
+}
+
+func bitFitness(program Program, output []byte, runtime int, err error, c TestCase) float64 {
+	if err != nil {
+		return 0
+	}
+	return distanceFitness(program, len(c.Expected), float64(bitDistance(output, c.Expected))/8)
+}
+
+// hammingDistance counts differing positions, extra characters count as different
+func hammingDistance(a, b []byte) int {
+	distance := 0
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if i >= len(a) || i >= len(b) || a[i] != b[i] {
+			distance++
+		}
+	}
+	return distance
+}
+
+// levenshteinDistance counts the insertions, deletions and substitutions
+func levenshteinDistance(a, b []byte) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// prefixDistance counts the characters outside the longest common prefix
+func prefixDistance(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return len(a) + len(b) - 2*i
+}
+
+// bitDistance counts differing bits, extra characters count as eight bits
+func bitDistance(a, b []byte) int {
+	distance := 0
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if i >= len(a) || i >= len(b) {
+			distance += 8
+		} else {
+			distance += bits.OnesCount8(a[i] ^ b[i])
+		}
+	}
+	return distance
+}
@@ -0,0 +1,85 @@
+package bf
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDistances(t *testing.T) {
+	if hammingDistance([]byte("abc"), []byte("abd")) != 1 {
+		t.Fail()
+	}
+	if hammingDistance([]byte("abc"), []byte("a")) != 2 {
+		t.Fail()
+	}
+	if levenshteinDistance([]byte("kitten"), []byte("sitting")) != 3 {
+		t.Fail()
+	}
+	if levenshteinDistance([]byte(""), []byte("abc")) != 3 {
+		t.Fail()
+	}
+	if prefixDistance([]byte("abx"), []byte("abcd")) != 3 {
+		t.Fail()
+	}
+	if bitDistance([]byte{0x0f}, []byte{0x00, 0x01}) != 12 {
+		t.Fail()
+	}
+}
+
+func TestBuiltinFitness(t *testing.T) {
+	c := TestCase{Expected: []byte("hi")}
+	for _, name := range FitnessNames() {
+		f, err := FitnessByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exact := f.Fitness(Program(`+.`), []byte("hi"), 2, nil, c)
+		longer := f.Fitness(Program(`++.`), []byte("hi"), 3, nil, c)
+		wrong := f.Fitness(Program(`+.`), []byte("ho"), 2, nil, c)
+		failed := f.Fitness(Program(`+.`), []byte("hi"), 2, errors.New("failed"), c)
+		if !(exact > longer && longer > wrong && wrong >= failed) {
+			t.Errorf("%s: %f %f %f %f", name, exact, longer, wrong, failed)
+		}
+		empty := TestCase{Input: []byte("x")}
+		none := f.Fitness(Program(`,`), nil, 1, nil, empty)
+		extra := f.Fitness(Program(`,.`), []byte("x"), 2, nil, empty)
+		if math.IsInf(extra, 0) || math.IsNaN(extra) || !(none > extra) {
+			t.Errorf("%s: empty expected %f %f", name, none, extra)
+		}
+	}
+}
+
+func TestCombinedFitness(t *testing.T) {
+	f, err := FitnessByName("hamming:2, levenshtein:0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := TestCase{Expected: []byte("abc")}
+	output := []byte("abd")
+	expected := 2*HammingFitness.Fitness(nil, output, 0, nil, c) + 0.5*LevenshteinFitness.Fitness(nil, output, 0, nil, c)
+	if f.Fitness(nil, output, 0, nil, c) != expected {
+		t.Fail()
+	}
+	if _, err := FitnessByName("unknown"); err == nil {
+		t.Fail()
+	}
+	if _, err := FitnessByName("hamming:x"); err == nil {
+		t.Fail()
+	}
+}
+
+func TestBitPopulation(t *testing.T) {
+	rand.Seed(2)
+	p := NewPopulation()
+	p.Expected = []byte("hi\n")
+	p.Fitness = BitFitness
+	p.MaxRuntime = 200
+	p.MaxManipulate = 1
+	p.Run(context.Background(), Criteria{MaxGenerations: 200, StopOnSuccess: true})
+	if _, ok := p.SuccessCode(); !ok {
+		t.Fail()
+	}
+}
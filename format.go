@@ -0,0 +1,233 @@
+package bf
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Wrap breaks the string into lines of at most at bytes, at zero or below
+// leaves the string unchanged
+func Wrap(s string, at int) string {
+	if at <= 0 {
+		return s
+	}
+	b := bytes.NewBufferString("")
+	for pos := 0; pos < len(s); pos += at {
+		if pos+at < len(s) {
+			b.WriteString(s[pos : pos+at])
+			b.WriteRune('\n')
+		} else {
+			b.WriteString(s[pos:])
+		}
+	}
+	return b.String()
+}
+
+// FormatOptions controls the layout of formatted programs
+type FormatOptions struct {
+	// Indent of every loop level, empty disables the indent
+	Indent string
+	// StripComments removes all text that isn't an instruction
+	StripComments bool
+	// Width wraps the instructions at the column, zero disables wrapping
+	Width int
+}
+
+// inlineLoopLength is the max length of loops that stay on a single line
+const inlineLoopLength = 16
+
+// token is an instruction or a comment
+type token struct {
+	instr   byte
+	comment string
+}
+
+func tokenize(program []byte) []token {
+	tokens := []token{}
+	start := -1
+	for n, b := range program {
+		if isInstruction(b) {
+			if start >= 0 {
+				tokens = append(tokens, token{comment: string(program[start:n])})
+				start = -1
+			}
+			tokens = append(tokens, token{instr: b})
+		} else if start < 0 {
+			start = n
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{comment: string(program[start:])})
+	}
+	return tokens
+}
+
+func isInstruction(b byte) bool {
+	return strings.IndexByte(string(InputInstructions), b) >= 0
+}
+
+// inlineLoops returns the length of the loops that start at the tokens and
+// stay on a single line, short loops without comments and I/O are inlined
+func inlineLoops(tokens []token) map[int]int {
+	inline := map[int]int{}
+	expanded := map[int]bool{}
+	stack := []int{}
+	// position of the tokens in the instructions
+	pos := make([]int, len(tokens)+1)
+	for n, t := range tokens {
+		pos[n+1] = pos[n]
+		if t.instr != 0 {
+			pos[n+1]++
+		}
+		switch {
+		case t.instr == '[':
+			stack = append(stack, n)
+		case t.instr == ']' && len(stack) > 0:
+			start := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if pos[n+1]-pos[start] <= inlineLoopLength && !expanded[start] {
+				inline[start] = n - start + 1
+			}
+		case t.instr == '.' || t.instr == ',' || strings.TrimSpace(t.comment) != "":
+			// loops with I/O and comments are expanded
+			for _, start := range stack {
+				expanded[start] = true
+			}
+		}
+	}
+	return inline
+}
+
+// formatter writes lines that are indented by loop depth
+type formatter struct {
+	FormatOptions
+	out        bytes.Buffer
+	line       []byte
+	lineDepth  int // loop depth at the start of the line
+	depth      int
+	breakAfter bool // end the line before the next instruction
+	blank      bool // the last line is blank
+}
+
+func (f *formatter) indent() int {
+	if len(f.line) > 0 {
+		return f.lineDepth * len(f.Indent)
+	}
+	return f.depth * len(f.Indent)
+}
+
+// write appends to the line
+func (f *formatter) write(b ...byte) {
+	if len(f.line) == 0 {
+		f.lineDepth = f.depth
+	}
+	f.line = append(f.line, b...)
+}
+
+// flush ends the current line
+func (f *formatter) flush() {
+	f.breakAfter = false
+	if len(f.line) == 0 {
+		return
+	}
+	f.out.WriteString(strings.Repeat(f.Indent, f.lineDepth))
+	f.out.Write(f.line)
+	f.out.WriteByte('\n')
+	f.line = f.line[:0]
+	f.blank = false
+}
+
+// code appends the instructions, the line is wrapped when they don't fit
+func (f *formatter) code(instructions []byte) {
+	if f.breakAfter {
+		f.flush()
+	}
+	if f.Width <= 0 {
+		f.write(instructions...)
+		return
+	}
+	if len(f.line) > 0 && f.indent()+len(f.line)+len(instructions) > f.Width {
+		f.flush()
+	}
+	for _, b := range instructions {
+		if len(f.line) > 0 && f.indent()+len(f.line) >= f.Width {
+			f.flush()
+		}
+		f.write(b)
+	}
+}
+
+// comment keeps the text of the comment, text on the line of the
+// instructions trails them and the other lines are written on their own
+func (f *formatter) comment(text string) {
+	lines := strings.Split(text, "\n")
+	for n, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line != "" && n == 0 && len(f.line) > 0:
+			f.write(' ')
+			f.write([]byte(line)...)
+			f.flush()
+		case line != "":
+			f.flush()
+			f.write([]byte(line)...)
+			f.flush()
+		case n > 0 && n < len(lines)-1 && !f.blank && f.out.Len() > 0:
+			// keep blank lines that separate parts of the program
+			f.flush()
+			f.out.WriteByte('\n')
+			f.blank = true
+		}
+	}
+}
+
+// Format lays out the program with indented loops and lines that end after
+// output, the instructions are unchanged
+func Format(program []byte, options FormatOptions) []byte {
+	f := &formatter{FormatOptions: options}
+	tokens := tokenize(program)
+	inline := inlineLoops(tokens)
+	for n := 0; n < len(tokens); n++ {
+		t := tokens[n]
+		if t.instr == 0 {
+			if !f.StripComments {
+				f.comment(t.comment)
+			}
+			continue
+		}
+		if length, ok := inline[n]; ok {
+			loop := []byte{}
+			for _, t := range tokens[n : n+length] {
+				if t.instr != 0 {
+					loop = append(loop, t.instr)
+				}
+			}
+			f.code(loop)
+			n += length - 1
+			continue
+		}
+		switch t.instr {
+		case '[':
+			f.code([]byte{'['})
+			f.depth++
+			f.breakAfter = true
+		case ']':
+			f.flush()
+			if f.depth > 0 {
+				f.depth--
+			}
+			f.code([]byte{']'})
+		case '.':
+			f.code([]byte{'.'})
+			f.breakAfter = true
+		default:
+			f.code([]byte{t.instr})
+		}
+	}
+	f.flush()
+	out := bytes.TrimRight(f.out.Bytes(), "\n")
+	if len(out) == 0 {
+		return out
+	}
+	return append(out, '\n')
+}
@@ -0,0 +1,65 @@
+package bf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	if Wrap("12345", 2) != "12\n34\n5" || Wrap("1234", 2) != "12\n34" || Wrap("123", 0) != "123" {
+		t.Fail()
+	}
+}
+
+func TestFormat(t *testing.T) {
+	program := []byte(`++[>++<-]>[ loop
+	.>] end`)
+	expected := "++[>++<-]>[ loop\n  .\n  >\n] end\n"
+	if got := string(Format(program, FormatOptions{Indent: "  "})); got != expected {
+		t.Fatalf("%q != %q", got, expected)
+	}
+	expected = "++\n[>\n++\n<-\n]>\n[\n  .\n  >\n]\n"
+	if got := string(Format(program, FormatOptions{StripComments: true, Width: 2, Indent: "  "})); got != expected {
+		t.Fatalf("%q != %q", got, expected)
+	}
+	expected = "++[>++<-]>[ loop\n.\n>\n] end\n"
+	if got := string(Format(program, FormatOptions{})); got != expected {
+		t.Fatalf("%q != %q", got, expected)
+	}
+}
+
+func TestFormatInstructions(t *testing.T) {
+	filenames, err := filepath.Glob("examples/*.bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := []FormatOptions{
+		{},
+		{StripComments: true},
+		{Width: 40, Indent: "\t"},
+		{Indent: "  "},
+		{StripComments: true, Width: 1},
+	}
+	programs := [][]byte{[]byte(`][ unbalanced [[`), []byte(`[..]+-.`)}
+	for _, filename := range filenames {
+		program, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		programs = append(programs, program)
+	}
+	for _, program := range programs {
+		for _, o := range options {
+			formatted := Format(program, o)
+			if !bytes.Equal(Normalize(commands(formatted)), Normalize(commands(program))) ||
+				!bytes.Equal(commands(formatted), commands(program)) {
+				t.Errorf("instructions changed by %+v", o)
+			}
+			if again := Format(formatted, o); !bytes.Equal(again, formatted) {
+				t.Errorf("formatting with %+v isn't stable", o)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package bf
+
+import (
+	"bytes"
+	"sort"
+)
+
+// cell is a tape position with a known value during generation
+type cell struct {
+	pos   int
+	value byte
+}
+
+// Generate returns a short program that prints the text. Cells are
+// initialized with a multiplication loop to values near the characters of the
+// text, each character is printed from the cell that requires the least
+// changes. Characters that aren't near a cell are set in a new cell using the
+// shortest known constant.
+func Generate(text []byte) Program {
+	best := generateFrom(text, nil, 0)
+	for multiplier := 2; multiplier <= 20; multiplier++ {
+		factors := nearestFactors(text, multiplier)
+		for k := 1; k <= len(factors) && k <= 8; k++ {
+			code := generateFrom(text, factors[:k], multiplier)
+			if len(code) < len(best) {
+				best = code
+			}
+		}
+	}
+	return best
+}
+
+// nearestFactors returns the factors that multiplied approximate the
+// characters, ordered by how often they are used
+func nearestFactors(text []byte, multiplier int) []int {
+	count := map[int]int{}
+	for _, ch := range text {
+		f := (int(ch) + multiplier/2) / multiplier
+		if f > 0 {
+			count[f]++
+		}
+	}
+	factors := []int{}
+	for f := range count {
+		factors = append(factors, f)
+	}
+	sort.Slice(factors, func(i, j int) bool {
+		if count[factors[i]] != count[factors[j]] {
+			return count[factors[i]] > count[factors[j]]
+		}
+		return factors[i] < factors[j]
+	})
+	return factors
+}
+
+func moveTo(from, to int) Program {
+	if to > from {
+		return Program(bytes.Repeat([]byte{'>'}, to-from))
+	}
+	return Program(bytes.Repeat([]byte{'<'}, from-to))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// generateFrom prints the text starting with cells initialized to multiples
+// of the factors using a counter in the first cell
+func generateFrom(text []byte, factors []int, multiplier int) Program {
+	code := NewProgram()
+	cells := []cell{}
+	free := 0
+	if len(factors) > 0 {
+		code = append(code, adjust(0, byte(multiplier))...)
+		code = append(code, '[')
+		for _, f := range factors {
+			code = append(code, '>')
+			code = append(code, adjust(0, byte(f))...)
+		}
+		code = append(code, moveTo(len(factors), 0)...)
+		code = append(code, `-]`...)
+		cells = append(cells, cell{pos: 0, value: 0})
+		for i, f := range factors {
+			cells = append(cells, cell{pos: i + 1, value: byte(f * multiplier)})
+		}
+		free = len(factors) + 1
+	}
+	ptr := 0
+	for _, ch := range text {
+		choice := -1
+		cost := abs(free-ptr) + len(DefaultConstants.Code(ch))
+		for i, c := range cells {
+			if n := abs(c.pos-ptr) + adjustLength(c.value, ch); n < cost {
+				choice = i
+				cost = n
+			}
+		}
+		if choice < 0 {
+			// a new cell to the right of the used cells is zero
+			code = append(code, moveTo(ptr, free)...)
+			code = append(code, DefaultConstants.Code(ch)...)
+			cells = append(cells, cell{pos: free, value: ch})
+			ptr = free
+			free++
+		} else {
+			c := &cells[choice]
+			code = append(code, moveTo(ptr, c.pos)...)
+			code = append(code, adjust(c.value, ch)...)
+			c.value = ch
+			ptr = c.pos
+		}
+		code = append(code, '.')
+	}
+	return code
+}
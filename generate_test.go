@@ -0,0 +1,48 @@
+package bf
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+const computer = "I Feel Like a Computer\n"
+
+func TestGenerate(t *testing.T) {
+	texts := []string{"", "A", "hi\n", "Hello World!\n", computer, "\x00\xff\x80\x01", "zzzzzzzzzz"}
+	for _, text := range texts {
+		code := Generate([]byte(text))
+		if output := run(t, code); output != text {
+			t.Errorf("%q != %q", output, text)
+		}
+	}
+	// the evolved program in the readme has length 377
+	if code := Generate([]byte(computer)); len(code) >= 377 {
+		t.Errorf("%d %s", len(code), code)
+	}
+}
+
+var evolved struct {
+	sync.Once
+	code Program
+}
+
+// go test -run=^$ -bench=Generate
+func BenchmarkGenerate(b *testing.B) {
+	evolved.Do(func() {
+		p := NewPopulation()
+		p.Expected = []byte(computer)
+		r := p.Run(context.Background(), Criteria{MaxGenerations: 5000, StopOnSuccess: true})
+		evolved.code = r.Code
+	})
+	if evolved.code == nil {
+		b.Fatal("no success")
+	}
+	b.ResetTimer()
+	var direct Program
+	for i := 0; i < b.N; i++ {
+		direct = Generate([]byte(computer))
+	}
+	b.ReportMetric(float64(len(direct)), "direct-length")
+	b.ReportMetric(float64(len(evolved.code)), "evolved-length")
+}
@@ -0,0 +1,177 @@
+package bf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Position in the source of a program, lines and columns start at one
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// SourceInstruction is an instruction at its position in the source
+type SourceInstruction struct {
+	Instr byte
+	Position
+}
+
+// SourceInstructions returns the instructions of the source with their
+// positions
+func SourceInstructions(source []byte) []SourceInstruction {
+	instructions := []SourceInstruction{}
+	pos := Position{Line: 1, Column: 1}
+	for n, b := range source {
+		pos.Offset = n
+		if isInstruction(b) {
+			instructions = append(instructions, SourceInstruction{Instr: b, Position: pos})
+		}
+		if b == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return instructions
+}
+
+// IssueKind classifies the issues of the linter
+type IssueKind string
+
+// Issues reported by the linter
+const (
+	// Unbalanced is a bracket without a matching bracket
+	Unbalanced IssueKind = "unbalanced"
+	// DeadLoop is a loop that starts on a cell that is always zero
+	DeadLoop IssueKind = "dead-loop"
+	// InfiniteLoop is a loop that never changes its condition
+	InfiniteLoop IssueKind = "infinite-loop"
+	// NegativePointer is the pointer moving left of the first cell
+	NegativePointer IssueKind = "negative-pointer"
+	// NoOp is a pair of instructions that cancel each other
+	NoOp IssueKind = "no-op"
+	// CommentCommand is an instruction inside the text of a comment
+	CommentCommand IssueKind = "comment-command"
+)
+
+// Issue found by the linter
+type Issue struct {
+	Kind IssueKind `json:"kind"`
+	Position
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Position, i.Kind, i.Message)
+}
+
+// linter checks the instructions
+type linter struct {
+	code   []SourceInstruction
+	issues []Issue
+}
+
+func (l *linter) report(kind IssueKind, pos Position, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{Kind: kind, Position: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// Lint reports suspicious code in the source of a program
+func Lint(source []byte) []Issue {
+	l := &linter{code: SourceInstructions(source)}
+	l.lintComments(source)
+	l.lintNoOps()
+	match, unmatched := matchLoops(l.code)
+	for _, n := range unmatched {
+		if l.code[n].Instr == '[' {
+			l.report(Unbalanced, l.code[n].Position, "unclosed [")
+		} else {
+			l.report(Unbalanced, l.code[n].Position, "unmatched ]")
+		}
+	}
+	if len(unmatched) == 0 {
+		l.lintLoops(analyzeLoops(l.code, match))
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Offset < l.issues[j].Offset
+	})
+	return l.issues
+}
+
+func (l *linter) lintNoOps() {
+	for n := 0; n+1 < len(l.code); n++ {
+		a, b := l.code[n].Instr, l.code[n+1].Instr
+		if inverse[a] == b {
+			l.report(NoOp, l.code[n].Position, "%c%c has no effect", a, b)
+			n++
+		}
+	}
+}
+
+func isAlphanumeric(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// maxProseInstructions is the longest run of instructions that is
+// considered part of the text of a comment, like an ellipsis
+const maxProseInstructions = 3
+
+// lintComments reports instructions that are part of words in comments, like
+// the punctuation at the end of a sentence
+func (l *linter) lintComments(source []byte) {
+	for _, c := range l.code {
+		n := c.Offset
+		if n > 0 && isInstruction(source[n-1]) {
+			// only the start of a run of instructions is reported
+			continue
+		}
+		end := n
+		for end < len(source) && isInstruction(source[end]) {
+			end++
+		}
+		if end-n > maxProseInstructions {
+			continue
+		}
+		if (n > 0 && isAlphanumeric(source[n-1])) || (end < len(source) && isAlphanumeric(source[end])) {
+			l.report(CommentCommand, c.Position, "comment contains the instructions %q", source[n:end])
+		}
+	}
+}
+
+// lintLoops reports the loops that never execute or never end and the
+// pointer moving left of the first cell, loops inside loops that never
+// execute aren't checked
+func (l *linter) lintLoops(a *loopAnalyzer) {
+	for _, n := range a.negative {
+		l.report(NegativePointer, l.code[n].Position, "pointer moves left of the first cell")
+	}
+	dead := 0
+	for n, c := range l.code {
+		if c.Instr != '[' || n < dead {
+			continue
+		}
+		loop := a.loops[n]
+		switch {
+		case loop.Entry == 0:
+			dead = a.match[n]
+			if dead == n+2 && (l.code[n+1].Instr == '-' || l.code[n+1].Instr == '+') {
+				// clearing a cell that is already zero is harmless
+				continue
+			}
+			l.report(DeadLoop, c.Position, "loop never executes because the cell is zero")
+		case loop.Deltas != nil && loop.Deltas[0] == 0:
+			// the body never changes the cell of the condition
+			if loop.Entry == Unknown {
+				l.report(InfiniteLoop, c.Position, "loop never ends once entered")
+			} else {
+				l.report(InfiniteLoop, c.Position, "loop never ends because the cell is nonzero")
+			}
+		}
+	}
+}
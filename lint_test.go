@@ -0,0 +1,54 @@
+package bf
+
+import "testing"
+
+func lintKinds(source string) []IssueKind {
+	kinds := []IssueKind{}
+	for _, issue := range Lint([]byte(source)) {
+		kinds = append(kinds, issue.Kind)
+	}
+	return kinds
+}
+
+func TestLint(t *testing.T) {
+	tests := map[string][]IssueKind{
+		``:                     {},
+		`+[>+<-]>.`:            {},
+		`[-]`:                  {},
+		`]+[`:                  {Unbalanced, Unbalanced},
+		`[.]`:                  {DeadLoop},
+		`,[-][.]`:              {DeadLoop},
+		`,[>+<-][.]`:           {DeadLoop},
+		`+[]`:                  {InfiniteLoop},
+		`+[>.<]`:               {InfiniteLoop},
+		`,[]`:                  {InfiniteLoop},
+		`,[>]`:                 {},
+		`>+<<`:                 {NegativePointer},
+		`+[<]<`:                {},
+		`,+-.><`:               {NoOp, NoOp},
+		`,. print the result.`: {CommentCommand},
+		`, e-mail`:             {CommentCommand},
+	}
+	for source, expected := range tests {
+		kinds := lintKinds(source)
+		if len(kinds) != len(expected) {
+			t.Errorf("%q: %v != %v", source, kinds, expected)
+			continue
+		}
+		for n := range kinds {
+			if kinds[n] != expected[n] {
+				t.Errorf("%q: %v != %v", source, kinds, expected)
+			}
+		}
+	}
+}
+
+func TestLintPosition(t *testing.T) {
+	issues := Lint([]byte("+\n+[\n]]"))
+	if len(issues) != 1 || issues[0].Line != 3 || issues[0].Column != 2 || issues[0].Offset != 6 {
+		t.Fatalf("unexpected issues %v", issues)
+	}
+	if got := issues[0].String(); got != "3:2: unbalanced: unmatched ]" {
+		t.Fatalf("unexpected message %q", got)
+	}
+}
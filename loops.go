@@ -0,0 +1,315 @@
+package bf
+
+import "sort"
+
+// Unknown is a value or number of iterations that can't be determined
+const Unknown = -1
+
+// Loop describes the effect of a single iteration of a loop, cells are
+// relative to the pointer at the start of the iteration
+type Loop struct {
+	Start Position // position of the [
+	End   Position // position of the ]
+	Depth int      // number of enclosing loops
+	// Tracked is false when a nested loop moves the pointer by an unknown
+	// amount, the cells after that loop are missing from Reads and Writes
+	Tracked bool
+	// Movement is the net pointer movement, valid when tracked
+	Movement int
+	// Balanced loops return the pointer to the cell where they started
+	Balanced bool
+	// Reads are the cells whose value is used, including the condition
+	Reads []int
+	// Writes are the cells that are modified
+	Writes []int
+	// Output is true when the loop or its nested loops write output
+	Output bool
+	// Counted is true when the iteration count follows from the value of the
+	// condition cell on entry, it changes by Step every iteration
+	Counted bool
+	Step    int
+	// Deltas are the changes of the cells per iteration of balanced loops
+	// without nested loops or input, nil otherwise
+	Deltas map[int]int
+	// Entry is the value of the condition cell when it is the same every
+	// time the loop is entered, Unknown otherwise
+	Entry int
+	// Iterations is the number of iterations when the value of the condition
+	// cell on entry is known and the same every time the loop is entered
+	Iterations int
+}
+
+// AnalyzeLoops returns the effect of the loops of the source on the tape in
+// the order of their start
+func AnalyzeLoops(source []byte) ([]*Loop, error) {
+	code := SourceInstructions(source)
+	match, unmatched := matchLoops(code)
+	if len(unmatched) > 0 {
+		return nil, ErrInvalidNesting
+	}
+	a := analyzeLoops(code, match)
+	loops := []*Loop{}
+	for n, c := range code {
+		if c.Instr == '[' {
+			loops = append(loops, a.loops[n])
+		}
+	}
+	return loops, nil
+}
+
+// matchLoops returns the matching bracket of the brackets and the brackets
+// without a match
+func matchLoops(code []SourceInstruction) (match []int, unmatched []int) {
+	match = make([]int, len(code))
+	stack := []int{}
+	for n, c := range code {
+		switch c.Instr {
+		case '[':
+			stack = append(stack, n)
+		case ']':
+			if len(stack) == 0 {
+				unmatched = append(unmatched, n)
+				continue
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			match[open], match[n] = n, open
+		}
+	}
+	return match, append(unmatched, stack...)
+}
+
+// cells is a set of cells relative to the pointer
+type cells map[int]bool
+
+func (c cells) sorted() []int {
+	s := []int{}
+	for cell := range c {
+		s = append(s, cell)
+	}
+	sort.Ints(s)
+	return s
+}
+
+// loopAnalyzer holds the balanced instructions and the loops that start at
+// them
+type loopAnalyzer struct {
+	code  []SourceInstruction
+	match []int
+	loops map[int]*Loop
+	// negative are the instructions that move the pointer left of the first
+	// cell
+	negative []int
+}
+
+// analyzeLoops determines the effect of the loops of the balanced code
+func analyzeLoops(code []SourceInstruction, match []int) *loopAnalyzer {
+	a := &loopAnalyzer{code: code, match: match, loops: map[int]*Loop{}}
+	for n, c := range code {
+		if c.Instr == '[' {
+			a.effect(n, 0)
+		}
+	}
+	a.walk(0, len(code), &tapeState{absolute: true, cells: map[int]int{}, zero: true})
+	return a
+}
+
+// effect determines the effect of an iteration of the loop at open
+func (a *loopAnalyzer) effect(open, depth int) *Loop {
+	if loop, ok := a.loops[open]; ok {
+		return loop
+	}
+	end := a.match[open]
+	loop := &Loop{
+		Start:      a.code[open].Position,
+		End:        a.code[end].Position,
+		Depth:      depth,
+		Tracked:    true,
+		Entry:      Unknown,
+		Iterations: Unknown,
+	}
+	a.loops[open] = loop
+	reads, writes := cells{0: true}, cells{}
+	deltas := map[int]int{}
+	simple, counted := true, true
+	ptr := 0
+	for n := open + 1; n < end; n++ {
+		instr := a.code[n].Instr
+		if instr == '[' {
+			inner := a.effect(n, depth+1)
+			simple = false
+			loop.Output = loop.Output || inner.Output
+			n = a.match[n]
+			if !loop.Tracked {
+				continue
+			}
+			for _, cell := range inner.Reads {
+				reads[ptr+cell] = true
+			}
+			for _, cell := range inner.Writes {
+				writes[ptr+cell] = true
+				if ptr+cell == 0 {
+					counted = false
+				}
+			}
+			if inner.Balanced {
+				ptr += inner.Movement
+			} else {
+				loop.Tracked = false
+			}
+			continue
+		}
+		switch instr {
+		case ',':
+			simple = false
+		case '.':
+			loop.Output = true
+		}
+		if !loop.Tracked {
+			continue
+		}
+		switch instr {
+		case '>':
+			ptr++
+		case '<':
+			ptr--
+		case '+', '-':
+			reads[ptr], writes[ptr] = true, true
+			if instr == '+' {
+				deltas[ptr]++
+			} else {
+				deltas[ptr]--
+			}
+		case '.':
+			reads[ptr] = true
+		case ',':
+			writes[ptr] = true
+			if ptr == 0 {
+				counted = false
+			}
+		}
+	}
+	if loop.Tracked {
+		loop.Movement = ptr
+		loop.Balanced = ptr == 0
+	}
+	loop.Reads, loop.Writes = reads.sorted(), writes.sorted()
+	step := signed(deltas[0])
+	loop.Counted = loop.Balanced && counted && step != 0
+	if loop.Counted {
+		loop.Step = step
+	}
+	if loop.Balanced && simple {
+		loop.Deltas = map[int]int{}
+		for cell, delta := range deltas {
+			if delta = signed(delta); delta != 0 {
+				loop.Deltas[cell] = delta
+			}
+		}
+	}
+	return loop
+}
+
+// signed returns the change of a cell as a value between -128 and 127
+func signed(delta int) int {
+	delta = (delta%256 + 256) % 256
+	if delta >= 128 {
+		delta -= 256
+	}
+	return delta
+}
+
+// iterations is the number of steps until the value reaches zero
+func iterations(value, step int) int {
+	for k := 0; k < 256; k++ {
+		if (value+k*step)%256 == 0 {
+			return k
+		}
+	}
+	return Unknown
+}
+
+// tapeState is what is known about the cells during straight-line code
+type tapeState struct {
+	ptr      int
+	absolute bool        // ptr is the index of the cell
+	cells    map[int]int // known values
+	zero     bool        // the other cells are zero
+}
+
+func (t *tapeState) value(cell int) int {
+	if v, ok := t.cells[cell]; ok {
+		return v
+	}
+	if t.zero {
+		return 0
+	}
+	return Unknown
+}
+
+// walk determines the values on entry of the loops between from and to
+func (a *loopAnalyzer) walk(from, to int, t *tapeState) {
+	for n := from; n < to; n++ {
+		switch a.code[n].Instr {
+		case '>':
+			t.ptr++
+		case '<':
+			t.ptr--
+			if t.absolute && t.ptr < 0 {
+				a.negative = append(a.negative, n)
+				t.absolute = false
+				t.zero = false
+			}
+		case '+', '-':
+			if v := t.value(t.ptr); v != Unknown {
+				delta := 1
+				if a.code[n].Instr == '-' {
+					delta = 255
+				}
+				t.cells[t.ptr] = (v + delta) % 256
+			}
+		case ',':
+			t.cells[t.ptr] = Unknown
+		case '[':
+			a.loop(n, t)
+			n = a.match[n]
+		}
+	}
+}
+
+// loop determines the iterations of the loop and updates the tape to the
+// state after the loop
+func (a *loopAnalyzer) loop(open int, t *tapeState) {
+	loop := a.loops[open]
+	v := t.value(t.ptr)
+	loop.Entry = v
+	switch {
+	case v == 0:
+		loop.Iterations = 0
+	case v != Unknown && loop.Counted:
+		loop.Iterations = iterations(v, loop.Step)
+	}
+	// every iteration starts at the same cell when the loop is balanced
+	absolute := t.absolute && loop.Balanced && loop.Iterations != 0
+	a.walk(open+1, a.match[open], &tapeState{ptr: t.ptr, absolute: absolute, cells: map[int]int{}})
+	switch {
+	case loop.Iterations == 0:
+		return
+	case loop.Iterations != Unknown && loop.Deltas != nil:
+		for cell, delta := range loop.Deltas {
+			if v := t.value(t.ptr + cell); v != Unknown {
+				t.cells[t.ptr+cell] = ((v+delta*loop.Iterations)%256 + 256) % 256
+			}
+		}
+	case loop.Balanced:
+		for _, cell := range loop.Writes {
+			t.cells[t.ptr+cell] = Unknown
+		}
+	default:
+		// the position of the pointer is unknown
+		t.cells = map[int]int{}
+		t.zero = false
+		t.absolute = false
+	}
+	t.cells[t.ptr] = 0
+}
@@ -0,0 +1,102 @@
+package bf
+
+import "regexp"
+
+// constantPattern matches code that adds a constant to a cell using a
+// multiplication loop with the cell to the right, or a sequence of changes
+var constantPattern = regexp.MustCompile(`>(\+*|-*)\[<(\+*|-*)>-\]<([+-]*)|[+-]{2,}`)
+
+// constantDelta returns the value that is added by code matching constantPattern
+func constantDelta(code Program) byte {
+	m := constantPattern.FindSubmatchIndex(code)
+	if m == nil || m[2] < 0 {
+		return changes(code)
+	}
+	counter := changes(code[m[2]:m[3]])
+	body := changes(code[m[4]:m[5]])
+	return counter*body + changes(code[m[6]:m[7]])
+}
+
+// changes sums a sequence of `+` and `-`
+func changes(code Program) byte {
+	var delta byte
+	for _, b := range code {
+		if b == '+' {
+			delta++
+		} else if b == '-' {
+			delta--
+		}
+	}
+	return delta
+}
+
+// Minimize shortens a successful program while all test cases keep passing,
+// report is called with every improvement. Constants are rewritten to the
+// shortest known code, instructions and loops are deleted and a number of
+// random mutations is attempted.
+func (p *Population) Minimize(program Program, attempts int, report func(Program)) Program {
+	best := Normalize(commands(program))
+	improve := func(candidate Program) bool {
+		if len(candidate) >= len(best) || !p.Verify(candidate) {
+			return false
+		}
+		best = candidate
+		if report != nil {
+			report(best)
+		}
+		return true
+	}
+	for improved := true; improved; {
+		improved = improve(Simplify(best))
+		improved = p.minimizeConstants(&best, improve) || improved
+		improved = p.minimizeDeletions(&best, improve) || improved
+	}
+	m := newMutator(p.rng, p.instructions(), p.OperatorWeights)
+	for i := 0; i < attempts; i++ {
+		mutated, _ := m.mutate(NewProgramClone(best), []Entry{{program: best}})
+		if improve(Normalize(mutated)) {
+			// continue with the deterministic steps
+			best = p.Minimize(best, 0, report)
+		}
+	}
+	return best
+}
+
+// minimizeConstants replaces constants with shorter code
+func (p *Population) minimizeConstants(best *Program, improve func(Program) bool) bool {
+	improved := false
+	matches := constantPattern.FindAllIndex(*best, -1)
+	// replace from the end to keep earlier positions valid
+	for k := len(matches) - 1; k >= 0; k-- {
+		start, end := matches[k][0], matches[k][1]
+		code := *best
+		replacement := DefaultConstants.Code(constantDelta(code[start:end]))
+		if len(replacement) >= end-start {
+			continue
+		}
+		candidate := replaceAt(NewProgramClone(code), start, end-start, replacement)
+		improved = improve(candidate) || improved
+	}
+	return improved
+}
+
+// minimizeDeletions removes single instructions and whole loops
+func (p *Population) minimizeDeletions(best *Program, improve func(Program) bool) bool {
+	improved := false
+	for i := 0; i < len(*best); {
+		code := *best
+		if code[i] == '[' {
+			end := matchingLoop(code, i)
+			if improve(removeAt(NewProgramClone(code), i, end-i+1)) {
+				improved = true
+				continue
+			}
+		}
+		if improve(Normalize(removeAt(NewProgramClone(code), i, 1))) {
+			improved = true
+			continue
+		}
+		i++
+	}
+	return improved
+}
@@ -0,0 +1,53 @@
+package bf
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConstantDelta(t *testing.T) {
+	for d := 0; d < 256; d++ {
+		code := DefaultConstants.Code(byte(d))
+		if err := calc([]byte{}, string(code), []byte{byte(d), 0}); err != nil {
+			t.Errorf("0x%02x = %s", d, code)
+		}
+		if constantDelta(code) != byte(d) {
+			t.Errorf("delta of %s", code)
+		}
+	}
+	if len(DefaultConstants.Code(64)) >= 64 || len(DefaultConstants.Code(255)) != 1 {
+		t.Fail()
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	p := NewPopulation()
+	p.Expected = []byte("AB")
+	program := Program(`>+++++[<+++++++++++++>-]<.>[-]<+-+.[-][>+<-]`)
+	if !p.Verify(program) {
+		t.Fatal("program should succeed")
+	}
+	improvements := 0
+	minimized := p.Minimize(program, 100, func(code Program) {
+		improvements++
+	})
+	if !p.Verify(minimized) || len(minimized) >= len(program) || improvements == 0 {
+		t.Errorf("%s not minimized", minimized)
+	}
+}
+
+func TestMinimizeEvolved(t *testing.T) {
+	p := NewPopulation()
+	p.Expected = []byte("hi\n")
+	p.MaxRuntime = 200
+	p.Seed(1)
+	p.Run(context.Background(), Criteria{MaxGenerations: 200, StopOnSuccess: true})
+	code, ok := p.SuccessCode()
+	if !ok {
+		t.Fatal("no success")
+	}
+	minimized := p.Minimize(code, 200, nil)
+	if !p.Verify(minimized) || len(minimized) > len(code) {
+		t.Errorf("%s not minimized from %s", minimized, code)
+	}
+}
@@ -0,0 +1,316 @@
+package bf
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mutation is the context available to mutation operators
+type Mutation struct {
+	Rand         *rand.Rand
+	Instructions Instructions
+	// Sources are the kept entries available for cross breeding
+	Sources []Entry
+	trees   []Tree // parsed sources
+}
+
+// MutationOperator changes a program, the program may be modified in place
+type MutationOperator interface {
+	Name() string
+	Mutate(code Program, m *Mutation) Program
+}
+
+type mutationOperator struct {
+	name   string
+	mutate func(code Program, m *Mutation) Program
+}
+
+func (o mutationOperator) Name() string {
+	return o.name
+}
+
+func (o mutationOperator) Mutate(code Program, m *Mutation) Program {
+	return o.mutate(code, m)
+}
+
+// NewMutationOperator constructs a named mutation operator from a function
+func NewMutationOperator(name string, mutate func(code Program, m *Mutation) Program) MutationOperator {
+	return mutationOperator{name: name, mutate: mutate}
+}
+
+var mutationOperators = []MutationOperator{}
+
+// RegisterMutationOperator adds an operator that populations use with a
+// default weight of one, an operator with the same name is replaced
+func RegisterMutationOperator(op MutationOperator) {
+	for i, registered := range mutationOperators {
+		if registered.Name() == op.Name() {
+			mutationOperators[i] = op
+			return
+		}
+	}
+	mutationOperators = append(mutationOperators, op)
+}
+
+// MutationOperators returns the names of the registered operators
+func MutationOperators() []string {
+	names := make([]string, len(mutationOperators))
+	for i, op := range mutationOperators {
+		names[i] = op.Name()
+	}
+	return names
+}
+
+// ParseOperatorWeights parses a comma separated list of name=weight pairs,
+// the weights must not sum to zero
+func ParseOperatorWeights(spec string) (map[string]float64, error) {
+	weights := map[string]float64{}
+	if spec == "" {
+		return weights, nil
+	}
+	total := 0.0
+	for _, part := range strings.Split(spec, ",") {
+		pos := strings.Index(part, "=")
+		if pos < 0 {
+			return nil, fmt.Errorf("Invalid operator weight %q", part)
+		}
+		name := strings.TrimSpace(part[:pos])
+		if !isMutationOperator(name) {
+			return nil, fmt.Errorf("Unknown mutation operator %q (choose from %s)", name, strings.Join(MutationOperators(), ", "))
+		}
+		weight, err := strconv.ParseFloat(part[pos+1:], 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("Invalid operator weight %q", part)
+		}
+		weights[name] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("Operator weights %q sum to zero", spec)
+	}
+	return weights, nil
+}
+
+func isMutationOperator(name string) bool {
+	for _, op := range mutationOperators {
+		if op.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// randomRange picks a random position and length in the code
+func randomRange(code Program, rng *rand.Rand) (int, int) {
+	pos := rng.Intn(len(code))
+	return pos, rng.Intn(len(code) - pos)
+}
+
+func init() {
+	RegisterMutationOperator(NewMutationOperator("insert", func(code Program, m *Mutation) Program {
+		pos, _ := randomRange(code, m.Rand)
+		return insertAt(code, pos, m.Instructions.RandomProgram(m.Rand, 1))
+	}))
+	RegisterMutationOperator(NewMutationOperator("delete", func(code Program, m *Mutation) Program {
+		pos, _ := randomRange(code, m.Rand)
+		return removeAt(code, pos, 1)
+	}))
+	RegisterMutationOperator(NewMutationOperator("replace", func(code Program, m *Mutation) Program {
+		pos, _ := randomRange(code, m.Rand)
+		p := NewProgramClone(code)
+		p[pos] = m.Instructions.Random(m.Rand)
+		return p
+	}))
+	RegisterMutationOperator(NewMutationOperator("duplicate", func(code Program, m *Mutation) Program {
+		pos, _ := randomRange(code, m.Rand)
+		apos, len := randomRange(code, m.Rand)
+		return insertAt(code, pos, NewProgramClone(code[apos:apos+len]))
+	}))
+	// crossover that keeps loops balanced
+	RegisterMutationOperator(NewMutationOperator("crossover-one-point", onePointCrossover))
+	RegisterMutationOperator(NewMutationOperator("crossover-two-point", twoPointCrossover))
+	RegisterMutationOperator(NewMutationOperator("crossover-uniform", uniformCrossover))
+	RegisterMutationOperator(NewMutationOperator("range-delete", func(code Program, m *Mutation) Program {
+		pos, length := randomRange(code, m.Rand)
+		return removeAt(code, pos, length)
+	}))
+	RegisterMutationOperator(NewMutationOperator("compound", func(code Program, m *Mutation) Program {
+		// insert some loops for variety
+		pos, _ := randomRange(code, m.Rand)
+		if m.Rand.Intn(2) == 0 {
+			return insertAt(code, pos, DefaultConstants.Code(byte(m.Rand.Intn(256))))
+		}
+		c := m.Rand.Intn(len(compounds))
+		return insertAt(code, pos, compounds[c])
+	}))
+	RegisterMutationOperator(NewMutationOperator("comment", func(code Program, m *Mutation) Program {
+		pos, length := randomRange(code, m.Rand)
+		comment := code[pos : pos+length].Comment()
+		without := removeAt(code, pos, length)
+		return insertAt(without, pos, comment)
+	}))
+}
+
+// OperatorStats counts how often an operator produced improved offspring
+type OperatorStats struct {
+	Name         string
+	Weight       float64
+	Attempts     int
+	Improvements int
+	// recent counts decay every generation for adaptive weights
+	recentAttempts     float64
+	recentImprovements float64
+}
+
+// Rate is the ratio of attempts that improved on the parent
+func (s OperatorStats) Rate() float64 {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return float64(s.Improvements) / float64(s.Attempts)
+}
+
+// adaptiveDecay is the factor that recent counts decay with every generation
+const adaptiveDecay = 0.9
+
+// adaptiveFloor keeps unsuccessful operators in use
+const adaptiveFloor = 0.05
+
+// mutator applies random mutations using its own random source and
+// picks operators according to their weights
+type mutator struct {
+	Mutation
+	operators []MutationOperator
+	weights   []float64
+	total     float64
+}
+
+// newMutator uses the registered operators, missing weights default to one
+func newMutator(rng *rand.Rand, set Instructions, weights map[string]float64) *mutator {
+	m := &mutator{Mutation: Mutation{Rand: rng, Instructions: set}}
+	for _, op := range mutationOperators {
+		weight := 1.0
+		if w, ok := weights[op.Name()]; ok {
+			weight = w
+		}
+		m.operators = append(m.operators, op)
+		m.weights = append(m.weights, weight)
+		m.total += weight
+	}
+	return m
+}
+
+// pick an operator proportional to the weights
+func (m *mutator) pick() int {
+	r := m.Rand.Float64() * m.total
+	for i, w := range m.weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(m.weights) - 1
+}
+
+// mutateTimes returns the mutated code and the names of the applied operators
+func (m *mutator) mutateTimes(code Program, times int, sources []Entry) (Program, []string) {
+	var applied []string
+	for i := 0; i < times; i++ {
+		var name string
+		code, name = m.mutate(code, sources)
+		if name != "" {
+			applied = append(applied, name)
+		}
+	}
+	return code, applied
+}
+
+func (m *mutator) mutate(code Program, sources []Entry) (Program, string) {
+	if len(code) == 0 || m.total <= 0 {
+		return m.Instructions.RandomProgram(m.Rand, 1), ""
+	}
+	op := m.operators[m.pick()]
+	if len(sources) != len(m.Sources) || len(sources) > 0 && &sources[0] != &m.Sources[0] {
+		// the trees of other sources
+		m.trees = nil
+	}
+	m.Sources = sources
+	return op.Mutate(code, &m.Mutation), op.Name()
+}
+
+// operatorStats returns the statistics for each registered operator
+func (p *Population) operatorStats() []*OperatorStats {
+	for _, op := range mutationOperators {
+		if _, ok := p.operators[op.Name()]; !ok {
+			p.operators[op.Name()] = &OperatorStats{Name: op.Name()}
+		}
+	}
+	stats := make([]*OperatorStats, len(mutationOperators))
+	for i, op := range mutationOperators {
+		stats[i] = p.operators[op.Name()]
+	}
+	return stats
+}
+
+// OperatorStats returns the usage and current weight of each operator
+func (p *Population) OperatorStats() []OperatorStats {
+	weights := p.operatorWeights()
+	stats := []OperatorStats{}
+	for _, s := range p.operatorStats() {
+		stat := *s
+		stat.Weight = weights[s.Name]
+		stats = append(stats, stat)
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Rate() > stats[j].Rate()
+	})
+	return stats
+}
+
+// operatorWeights combines the user weights with the recent success
+// rate of the operators when adaptive
+func (p *Population) operatorWeights() map[string]float64 {
+	weights := map[string]float64{}
+	for _, s := range p.operatorStats() {
+		weight := 1.0
+		if w, ok := p.OperatorWeights[s.Name]; ok {
+			weight = w
+		}
+		if p.Adaptive {
+			rate := (s.recentImprovements + 1) / (s.recentAttempts + 2)
+			weight *= adaptiveFloor + rate
+		}
+		weights[s.Name] = weight
+	}
+	return weights
+}
+
+// creditOperators counts the attempts and improvements of the operators
+// that produced the evaluated entries
+func (p *Population) creditOperators() {
+	stats := p.operatorStats()
+	for _, s := range stats {
+		s.recentAttempts *= adaptiveDecay
+		s.recentImprovements *= adaptiveDecay
+	}
+	for i := range p.entries {
+		e := &p.entries[i]
+		improved := e.fitness > e.parentFitness
+		for _, name := range e.operators {
+			s, ok := p.operators[name]
+			if !ok {
+				continue
+			}
+			s.Attempts++
+			s.recentAttempts++
+			if improved {
+				s.Improvements++
+				s.recentImprovements++
+			}
+		}
+		e.operators = nil
+	}
+}
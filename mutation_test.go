@@ -0,0 +1,97 @@
+package bf
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestMutationOperators(t *testing.T) {
+	names := []string{"insert", "delete", "replace", "duplicate", "crossover-one-point", "range-delete", "compound", "comment"}
+	for _, name := range names {
+		if !isMutationOperator(name) {
+			t.Errorf("operator %q not registered", name)
+		}
+	}
+	sources := []Entry{{program: Program(`++.`)}, {program: Program{}}}
+	for _, op := range mutationOperators {
+		m := &Mutation{Rand: rand.New(rand.NewSource(1)), Instructions: OutputInstructions, Sources: sources}
+		for i := 0; i < 100; i++ {
+			code := op.Mutate(Program(`+[>+<-].`), m)
+			if len(code) == 0 && (op.Name() == "insert" || op.Name() == "compound") {
+				t.Errorf("%s returned empty program", op.Name())
+			}
+		}
+	}
+}
+
+func TestParseOperatorWeights(t *testing.T) {
+	weights, err := ParseOperatorWeights("insert=2, crossover-uniform=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights["insert"] != 2 || weights["crossover-uniform"] != 0.5 || len(weights) != 2 {
+		t.Fatalf("unexpected weights %v", weights)
+	}
+	for _, spec := range []string{"unknown=1", "insert", "insert=x", "insert=-1", "insert=0", "insert=0,delete=0"} {
+		if _, err := ParseOperatorWeights(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestOperatorWeights(t *testing.T) {
+	weights := map[string]float64{}
+	for _, name := range MutationOperators() {
+		weights[name] = 0
+	}
+	weights["replace"] = 1
+	m := newMutator(rand.New(rand.NewSource(1)), OutputInstructions, weights)
+	code := Program(`+++`)
+	for i := 0; i < 100; i++ {
+		var name string
+		code, name = m.mutate(code, nil)
+		if name != "replace" || len(code) != 3 {
+			t.Fatalf("expected replace only, got %s", name)
+		}
+	}
+}
+
+func TestAdaptiveOperators(t *testing.T) {
+	p := seededPopulation()
+	p.Adaptive = true
+	for i := 0; i < 10; i++ {
+		p.EvaluateAndMutate()
+	}
+	attempts := 0
+	for _, s := range p.OperatorStats() {
+		attempts += s.Attempts
+		if s.Weight <= 0 {
+			t.Errorf("operator %s has weight %f", s.Name, s.Weight)
+		}
+	}
+	if attempts == 0 {
+		t.Fatal("no operator attempts counted")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := p.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := LoadPopulation(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Adaptive {
+		t.Fatal("adaptive not restored")
+	}
+	for i := 0; i < 5; i++ {
+		p.EvaluateAndMutate()
+		resumed.EvaluateAndMutate()
+	}
+	for i := range p.entries {
+		if !bytes.Equal(resumed.entries[i].program, p.entries[i].program) {
+			t.Fatalf("entry %d differs", i)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package bf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EOFPolicy determines the value of a cell when reading past the end of input
+type EOFPolicy int
+
+// EOF policies
+const (
+	// EOFZero sets the cell to zero
+	EOFZero EOFPolicy = iota
+	// EOFUnchanged leaves the cell unchanged
+	EOFUnchanged
+	// EOFMinusOne sets all bits of the cell
+	EOFMinusOne
+)
+
+var eofPolicyNames = map[EOFPolicy]string{
+	EOFZero:      "zero",
+	EOFUnchanged: "unchanged",
+	EOFMinusOne:  "minus-one",
+}
+
+func (e EOFPolicy) String() string {
+	return eofPolicyNames[e]
+}
+
+// EOFPolicyNames returns the names of the EOF policies
+func EOFPolicyNames() []string {
+	names := []string{}
+	for e := EOFZero; int(e) < len(eofPolicyNames); e++ {
+		names = append(names, e.String())
+	}
+	return names
+}
+
+// EOFPolicyByName returns the EOF policy with the name
+func EOFPolicyByName(name string) (EOFPolicy, error) {
+	for e, n := range eofPolicyNames {
+		if n == name {
+			return e, nil
+		}
+	}
+	return EOFZero, fmt.Errorf("Unknown EOF policy %q (choose from %s)", name, strings.Join(EOFPolicyNames(), ", "))
+}
+
+// TapePolicy determines what happens when the pointer leaves the tape
+type TapePolicy int
+
+// Tape policies
+const (
+	// TapeGrow grows the tape to the right on demand up to the tape size
+	// when given, moving left of the first cell is an error
+	TapeGrow TapePolicy = iota
+	// TapeWrap wraps the pointer around both ends of the tape
+	TapeWrap
+	// TapeError reports moving the pointer off either end of the tape
+	TapeError
+)
+
+var tapePolicyNames = map[TapePolicy]string{
+	TapeGrow:  "grow",
+	TapeWrap:  "wrap",
+	TapeError: "error",
+}
+
+func (t TapePolicy) String() string {
+	return tapePolicyNames[t]
+}
+
+// TapePolicyNames returns the names of the tape policies
+func TapePolicyNames() []string {
+	names := []string{}
+	for t := TapeGrow; int(t) < len(tapePolicyNames); t++ {
+		names = append(names, t.String())
+	}
+	return names
+}
+
+// TapePolicyByName returns the tape policy with the name
+func TapePolicyByName(name string) (TapePolicy, error) {
+	for t, n := range tapePolicyNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return TapeGrow, fmt.Errorf("Unknown tape policy %q (choose from %s)", name, strings.Join(TapePolicyNames(), ", "))
+}
+
+// defaultTapeSize is the number of cells of a fixed size tape
+const defaultTapeSize = 30000
+
+// Options for running programs, the zero value behaves like the original
+// interpreter
+type Options struct {
+	// CellWidth is the number of bits of a cell (8, 16 or 32), zero is 8
+	CellWidth int
+	EOF       EOFPolicy
+	Tape      TapePolicy
+	// TapeSize is the number of cells, zero is unlimited when the tape grows
+	// and 30000 otherwise
+	TapeSize int
+	// MaxInstructions limits the executed instructions, zero is unlimited
+	MaxInstructions int
+	// Deadline stops the program when the time has passed, zero is no deadline
+	Deadline time.Time
+}
+
+// cellBytes returns the number of bytes of a cell
+func (o Options) cellBytes() (int, error) {
+	switch o.CellWidth {
+	case 0, 8:
+		return 1, nil
+	case 16:
+		return 2, nil
+	case 32:
+		return 4, nil
+	}
+	return 0, ErrUnsupported
+}
+
+// tapeSize returns the number of cells of a fixed size tape
+func (o Options) tapeSize() int {
+	if o.TapeSize > 0 {
+		return o.TapeSize
+	}
+	return defaultTapeSize
+}
+
+// deadlineInterval is the number of instructions between deadline checks
+const deadlineInterval = 1 << 16
+
+// expired reports if the deadline has passed
+func (o Options) expired() bool {
+	return !o.Deadline.IsZero() && time.Now().After(o.Deadline)
+}
+
+// RunStats describes the execution of a program
+type RunStats struct {
+	// Instructions that were executed, comments are not counted
+	Instructions int
+	// MaxTape is the number of cells up to the rightmost cell that was used
+	MaxTape int
+}
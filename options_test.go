@@ -0,0 +1,93 @@
+package bf
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEOFPolicy(t *testing.T) {
+	expected := map[EOFPolicy]string{EOFZero: "\x00", EOFUnchanged: "\x05", EOFMinusOne: "\xff"}
+	for eof, want := range expected {
+		out := &strings.Builder{}
+		i := NewInterpreter(out, strings.NewReader(""))
+		i.EOF = eof
+		if err := i.Interpret(code(`+++++,.`)); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("%s: %q != %q", eof, out.String(), want)
+		}
+	}
+}
+
+func TestTapePolicy(t *testing.T) {
+	i := NewInterpreter(nil, nil)
+	i.Tape = TapeError
+	i.TapeSize = 10
+	if err := i.Interpret(code(`+[>+]`)); err != ErrTape {
+		t.Fatalf("expected tape error, got %v", err)
+	}
+	if stats := i.RunStats(); stats.MaxTape != 10 {
+		t.Fatalf("max tape %d", stats.MaxTape)
+	}
+
+	out := &strings.Builder{}
+	i = NewInterpreter(out, nil)
+	i.Tape = TapeWrap
+	i.TapeSize = 3
+	if err := i.Interpret(code(`<+++>>>.`)); err != nil || out.String() != "\x03" {
+		t.Fatalf("wrap %q %v", out.String(), err)
+	}
+
+	i = NewInterpreter(nil, nil)
+	i.TapeSize = 2000
+	if err := i.Interpret(code(`+[>+]`)); err != ErrTape {
+		t.Fatalf("expected tape error when growing, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	i := NewInterpreter(nil, nil)
+	i.MaxInstructions = 100
+	if err := i.Interpret(code(`+[]`)); err != ErrExhaustedRuntime {
+		t.Fatalf("expected exhausted runtime, got %v", err)
+	}
+	i = NewInterpreter(ioutil.Discard, nil)
+	i.MaxInstructions = 4
+	if err := i.Interpret(code(`+ + + .`)); err != nil || i.RunStats().Instructions != 4 {
+		t.Fatalf("comments counted as instructions: %v", err)
+	}
+	i = NewInterpreter(ioutil.Discard, nil)
+	i.MaxInstructions = 3
+	if err := i.Interpret(code(`+ + + .`)); err != ErrExhaustedRuntime {
+		t.Fatalf("expected exhausted runtime, got %v", err)
+	}
+	i = NewInterpreter(nil, nil)
+	i.Deadline = time.Now().Add(10 * time.Millisecond)
+	if err := i.Interpret(code(`+[]`)); err != ErrDeadline {
+		t.Fatalf("expected deadline, got %v", err)
+	}
+	i = NewInterpreter(nil, nil)
+	i.CellWidth = 16
+	if err := i.Interpret(code(`+`)); err != ErrUnsupported {
+		t.Fatalf("expected unsupported, got %v", err)
+	}
+}
+
+func TestPolicyNames(t *testing.T) {
+	for _, name := range EOFPolicyNames() {
+		if e, err := EOFPolicyByName(name); err != nil || e.String() != name {
+			t.Errorf("eof policy %s", name)
+		}
+	}
+	for _, name := range TapePolicyNames() {
+		if p, err := TapePolicyByName(name); err != nil || p.String() != name {
+			t.Errorf("tape policy %s", name)
+		}
+	}
+	if _, err := TapePolicyByName("circular"); err == nil {
+		t.Error("expected unknown tape policy")
+	}
+}
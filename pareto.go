@@ -0,0 +1,170 @@
+package bf
+
+import (
+	"math"
+	"sort"
+)
+
+// objectives of an entry, correctness is maximized while program length
+// and runtime are minimized, correctness excludes the length and runtime
+// bonuses of the fitness so that the objectives are independent
+type objectives struct {
+	correctness float64
+	length      int
+	runtime     int
+}
+
+func (e *Entry) objectives() objectives {
+	runtime := e.runtime
+	if e.err != nil {
+		// failing programs are considered to be the slowest
+		runtime = math.MaxInt32
+	}
+	return objectives{
+		correctness: e.correctness,
+		length:      len(e.program),
+		runtime:     runtime,
+	}
+}
+
+// dominates when at least as good in all objectives and better in one
+func (o objectives) dominates(other objectives) bool {
+	if o.correctness < other.correctness || o.length > other.length || o.runtime > other.runtime {
+		return false
+	}
+	return o.correctness > other.correctness || o.length < other.length || o.runtime < other.runtime
+}
+
+// nonDominatedSort assigns the pareto rank and crowding distance to the entries
+// and orders them by rank and decreasing crowding distance (NSGA-II)
+func nonDominatedSort(entries []Entry) {
+	n := len(entries)
+	objs := make([]objectives, n)
+	for i := range entries {
+		objs[i] = entries[i].objectives()
+	}
+	dominatedBy := make([]int, n)
+	dominates := make([][]int, n)
+	front := []int{}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if objs[i].dominates(objs[j]) {
+				dominates[i] = append(dominates[i], j)
+				dominatedBy[j]++
+			} else if objs[j].dominates(objs[i]) {
+				dominates[j] = append(dominates[j], i)
+				dominatedBy[i]++
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		if dominatedBy[i] == 0 {
+			front = append(front, i)
+		}
+	}
+	for rank := 0; len(front) > 0; rank++ {
+		crowdingDistance(entries, objs, front)
+		next := []int{}
+		for _, i := range front {
+			entries[i].rank = rank
+			for _, j := range dominates[i] {
+				dominatedBy[j]--
+				if dominatedBy[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		front = next
+	}
+	sort.Stable(byRank(entries))
+}
+
+// crowdingDistance estimates the density of entries surrounding each entry of a front
+func crowdingDistance(entries []Entry, objs []objectives, front []int) {
+	for _, i := range front {
+		entries[i].crowding = 0
+	}
+	values := []func(o objectives) float64{
+		func(o objectives) float64 { return o.correctness },
+		func(o objectives) float64 { return float64(o.length) },
+		func(o objectives) float64 { return float64(o.runtime) },
+	}
+	sorted := append([]int{}, front...)
+	for _, value := range values {
+		sort.SliceStable(sorted, func(a, b int) bool {
+			return value(objs[sorted[a]]) < value(objs[sorted[b]])
+		})
+		first := sorted[0]
+		last := sorted[len(sorted)-1]
+		entries[first].crowding = math.Inf(1)
+		entries[last].crowding = math.Inf(1)
+		span := value(objs[last]) - value(objs[first])
+		if span == 0 {
+			continue
+		}
+		for k := 1; k < len(sorted)-1; k++ {
+			distance := (value(objs[sorted[k+1]]) - value(objs[sorted[k-1]])) / span
+			entries[sorted[k]].crowding += distance
+		}
+	}
+}
+
+type byRank []Entry
+
+func (e byRank) Len() int      { return len(e) }
+func (e byRank) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e byRank) Less(i, j int) bool {
+	if e[i].rank != e[j].rank {
+		return e[i].rank < e[j].rank
+	}
+	return e[i].crowding > e[j].crowding
+}
+
+// updateFront merges the successful entries into the archive of successful
+// programs that are not dominated in length and runtime, the archive is only
+// kept in multi-objective mode
+func (p *Population) updateFront() {
+	candidates := append([]Entry{}, p.front...)
+	seen := map[string]bool{}
+	for _, e := range p.front {
+		seen[string(e.program)] = true
+	}
+	for _, e := range p.entries {
+		if !e.success {
+			continue
+		}
+		// the front is ranked on the programs that it returns
+		e.program = Normalize(e.program)
+		if !seen[string(e.program)] {
+			seen[string(e.program)] = true
+			candidates = append(candidates, e)
+		}
+	}
+	front := []Entry{}
+	for i := range candidates {
+		dominated := false
+		oi := candidates[i].objectives()
+		for j := range candidates {
+			oj := candidates[j].objectives()
+			better := oj.length < oi.length || oj.runtime < oi.runtime
+			same := oj.length == oi.length && oj.runtime == oi.runtime
+			if oj.length <= oi.length && oj.runtime <= oi.runtime && (better || (same && j < i)) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front = append(front, candidates[i])
+		}
+	}
+	sort.SliceStable(front, func(i, j int) bool {
+		return len(front[i].program) < len(front[j].program)
+	})
+	p.front = front
+}
+
+// Front returns the normalized successful programs found so far that are not
+// dominated by shorter or faster programs, ordered by program length
+func (p *Population) Front() []Entry {
+	return p.front
+}
@@ -0,0 +1,23 @@
+package bf
+
+import "testing"
+
+func TestNonDominatedSort(t *testing.T) {
+	entries := []Entry{
+		{program: Program(`+++`), correctness: -1, runtime: 3},
+		{program: Program(`+`), correctness: -1, runtime: 1},
+		{program: Program(`++`), correctness: 0, runtime: 2},
+		{program: Program(`++++`), correctness: -2, runtime: 4},
+	}
+	nonDominatedSort(entries)
+	ranks := map[string]int{}
+	for _, e := range entries {
+		ranks[string(e.program)] = e.rank
+	}
+	if ranks["+"] != 0 || ranks["++"] != 0 || ranks["+++"] != 1 || ranks["++++"] != 2 {
+		t.Errorf("%v", ranks)
+	}
+	if entries[0].rank != 0 || entries[3].rank != 2 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,85 @@
+package bf
+
+import (
+	"fmt"
+	"io"
+)
+
+// UndefinedProcedureError is returned when a procedure is called that
+// isn't defined
+type UndefinedProcedureError struct {
+	Procedure byte
+}
+
+func (e *UndefinedProcedureError) Error() string {
+	return fmt.Sprintf("Undefined procedure %d", e.Procedure)
+}
+
+// StackOverflowError is returned when procedure calls are nested too deep
+type StackOverflowError struct {
+	Depth int
+}
+
+func (e *StackOverflowError) Error() string {
+	return fmt.Sprintf("Stack overflow at call depth %d", e.Depth)
+}
+
+// procedure defines a procedure numbered by the current cell, calls the
+// procedure numbered by the current cell or returns from a procedure
+func (i *Interpreter) procedure(code byte, r io.Reader, strict bool) error {
+	switch code {
+	case '(':
+		if i.procedures == nil {
+			i.procedures = map[byte]int{}
+		}
+		i.procedures[i.memory[i.ptr]] = i.ip
+		return i.skipProcedure(r, strict)
+	case ')':
+		if len(i.calls) == 0 {
+			if strict {
+				return ErrInvalidNesting
+			}
+			return nil
+		}
+		top := len(i.calls) - 1
+		i.ip = i.calls[top]
+		i.calls = i.calls[:top]
+	case ':':
+		start, ok := i.procedures[i.memory[i.ptr]]
+		if !ok {
+			return &UndefinedProcedureError{Procedure: i.memory[i.ptr]}
+		}
+		if len(i.calls) >= stackSize {
+			return &StackOverflowError{Depth: len(i.calls)}
+		}
+		i.calls = append(i.calls, i.ip)
+		i.ip = start
+	}
+	return nil
+}
+
+// skipProcedure skips the body of a procedure definition, an unclosed
+// definition ends the program unless strict
+func (i *Interpreter) skipProcedure(r io.Reader, strict bool) error {
+	level := 0
+	for {
+		code, err := i.instr(r)
+		if err == io.EOF {
+			if strict {
+				return ErrInvalidNesting
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if code == '(' {
+			level++
+		} else if code == ')' {
+			if level == 0 {
+				return nil
+			}
+			level--
+		}
+	}
+}
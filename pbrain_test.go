@@ -0,0 +1,58 @@
+package bf
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestPBrain(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"(+++.):", "\x03"},
+		{"+(>+++.<)::", "\x03\x06"},
+		// loops inside procedures and nested definitions are skipped
+		{"+(>++[<.>-]<)>(())<:", "\x01\x01"},
+		// procedures can call other procedures
+		{"(>+.<)+(-:+):", "\x01"},
+	}
+	for _, test := range tests {
+		if out := extended(t, PBrain, test.program, ""); out != test.expected {
+			t.Errorf("%q printed %q expected %q", test.program, out, test.expected)
+		}
+	}
+	if out := extended(t, NoExtension, "(+++.):", ""); out != "\x03" {
+		t.Errorf("printed %q", out)
+	}
+}
+
+func TestPBrainErrors(t *testing.T) {
+	i := NewInterpreter(ioutil.Discard, nil)
+	i.Extension = PBrain
+	err := i.Interpret(code("(+++.)::"))
+	if e, ok := err.(*UndefinedProcedureError); !ok || e.Procedure != 3 {
+		t.Fatal(err)
+	}
+	i = NewInterpreter(ioutil.Discard, nil)
+	i.Extension = PBrain
+	err = i.Interpret(code("(:):"))
+	if e, ok := err.(*StackOverflowError); !ok || e.Depth != stackSize {
+		t.Fatal(err)
+	}
+	i = NewInterpreter(ioutil.Discard, nil)
+	i.Extension = PBrain
+	if err := i.Interpret(code(")")); err != ErrInvalidNesting {
+		t.Fatal(err)
+	}
+	i = NewInterpreter(ioutil.Discard, nil)
+	i.Extension = PBrain
+	if err := i.Interpret(code("(+++")); err != ErrInvalidNesting {
+		t.Fatal(err)
+	}
+	i = NewInterpreter(ioutil.Discard, nil)
+	i.Extension = PBrain
+	if _, err := i.InterpretExtended(code("(+++"), false, -1); err != nil {
+		t.Fatal(err)
+	}
+}
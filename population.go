@@ -3,73 +3,180 @@ package bf
 import (
 	"bytes"
 	"fmt"
-	"math"
 	"math/rand"
-	"sort"
+	"time"
 )
 
 const keepSize = 32
 const manipulationSize = 32
 const populationSize = keepSize * manipulationSize
 
+// TestCase is an input for a program together with the expected output
+type TestCase struct {
+	Input    []byte
+	Expected []byte
+}
+
 // Population maintains the pool of programs in the form of entries
 type Population struct {
-	entries       []Entry
-	Expected      []byte
+	entries []Entry
+	// Expected is used as single test case without input when Cases is empty
+	Expected []byte
+	Cases    []TestCase
+	Fitness  Fitness
+	// FitnessSpec is the FitnessByName spec of Fitness, checkpoints save the
+	// spec because functions can't be saved
+	FitnessSpec   string
 	MaxRuntime    int
 	MaxManipulate int
+	// MultiObjective selects using pareto ranks of correctness, program
+	// length and runtime instead of fitness alone
+	MultiObjective bool
+	// Simplify the success code when the simplified program still succeeds
+	Simplify bool
+	// OperatorWeights are the relative weights of the mutation operators by name
+	OperatorWeights map[string]float64
+	// Adaptive boosts the weights of operators that recently improved offspring
+	Adaptive bool
+	// Cache remembers evaluations of programs, nil disables caching
+	Cache *Cache
+	// Diversity keeps the kept entries from becoming clones
+	Diversity  Diversity
+	archive    [][]byte
+	operators  map[string]*OperatorStats
+	front      []Entry
+	generation int
+	src        *source
+	rng        *rand.Rand
+	stats      Stats
+	observers  []Observer
 }
 
 // Entry maintains information of a program
 type Entry struct {
-	program    Program
-	output     []byte
-	runtime    int
-	err        error
-	fitness    float64
-	success    bool
-	generation int
+	program Program
+	output  []byte
+	runtime int
+	err     error
+	fitness float64
+	// correctness is the negative bit distance of the outputs
+	correctness float64
+	success     bool
+	generation  int
+	rank        int
+	crowding    float64
+	// score is the fitness adjusted for diversity
+	score float64
+	// operators that produced the entry from a parent
+	operators     []string
+	parentFitness float64
 }
 
 // NewPopulation constructor
 func NewPopulation() *Population {
-	entries := make([]Entry, populationSize)
-	for i := range entries {
-		entries[i].program = NewRandomProgram(1)
-	}
-	return &Population{
-		entries:       entries,
+	src := &source{}
+	p := &Population{
+		entries:       make([]Entry, populationSize),
+		Fitness:       DefaultFitness,
 		MaxRuntime:    10000,
 		MaxManipulate: 3,
+		src:           src,
+		rng:           rand.New(src),
+		operators:     map[string]*OperatorStats{},
 	}
+	p.Seed(rand.Int63())
+	return p
+}
+
+// Seed initializes the random source and the initial random programs,
+// populations with the same seed and parameters evolve identically
+func (p *Population) Seed(seed int64) {
+	p.src.Seed(seed)
+	p.generation = 0
+	p.front = nil
+	p.archive = nil
+	set := p.instructions()
+	for i := range p.entries {
+		p.entries[i] = Entry{program: set.RandomProgram(p.rng, 1)}
+	}
+}
+
+// Generation returns the number of evaluated generations
+func (p *Population) Generation() int {
+	return p.generation
 }
 
-// Fittest is the top performing program
+// Fittest is the top performing program of the kept entries
 func (p *Population) Fittest() *Entry {
-	return &p.entries[0]
+	fittest := &p.entries[0]
+	for i := range p.entries[:keepSize] {
+		if p.entries[i].fitness > fittest.fitness {
+			fittest = &p.entries[i]
+		}
+	}
+	return fittest
 }
 
 // SuccessCode returns the program code when success is reached
 func (p *Population) SuccessCode() (Program, bool) {
 	if p.Fittest().success {
-		return Normalize(p.Fittest().program), true
+		code := Normalize(p.Fittest().program)
+		if p.Simplify {
+			if simplified := Simplify(code); p.Verify(simplified) {
+				return simplified, true
+			}
+		}
+		return code, true
 	}
 	return nil, false
 }
 
+// Verify executes the program and reports if all test cases pass
+func (p *Population) Verify(program Program) bool {
+	e := Entry{program: program}
+	e.evaluate(p.testCases(), p.Fitness, p.MaxRuntime, p.Cache)
+	return e.success
+}
+
+// testCases returns the cases the programs are evaluated against
+func (p *Population) testCases() []TestCase {
+	if len(p.Cases) > 0 {
+		return p.Cases
+	}
+	return []TestCase{{Expected: p.Expected}}
+}
+
+// instructions returns the instruction set used for generating code,
+// input instructions are only generated when a test case has input
+func (p *Population) instructions() Instructions {
+	for _, c := range p.Cases {
+		if len(c.Input) > 0 {
+			return InputInstructions
+		}
+	}
+	return OutputInstructions
+}
+
 // EvaluateAndMutate will execute programs and evaluate fitness and mutate
 func (p *Population) EvaluateAndMutate() {
+	start := time.Now()
+	cases := p.testCases()
+	hits, misses := p.Cache.Hits(), p.Cache.Misses()
 	for i := range p.entries {
-		entry := &p.entries[i]
-		entry.fitness = 0.0
-		entry.err = entry.exec("", p.MaxRuntime)
-		entry.success = entry.err == nil && bytes.Equal(entry.output, p.Expected)
-		if entry.err == nil {
-			entry.calculateFitness(p.Expected)
-		}
+		p.entries[i].evaluate(cases, p.Fitness, p.MaxRuntime, p.Cache)
+	}
+	elapsed := time.Since(start)
+	p.creditOperators()
+
+	p.selectEntries()
+	if p.MultiObjective {
+		p.updateFront()
 	}
+	p.generation++
+	p.stats = p.calculateStats(len(p.entries)*len(cases), elapsed)
+	p.stats.CacheHitRate = hitRate(p.Cache.Hits()-hits, p.Cache.Misses()-misses)
 
-	sort.Stable(byFitness(p.entries))
+	mutator := newMutator(p.rng, p.instructions(), p.operatorWeights())
 
 	for i := 0; i < keepSize; i++ {
 		keepEntry := &p.entries[i]
@@ -78,72 +185,97 @@ func (p *Population) EvaluateAndMutate() {
 			entry := &p.entries[(m*keepSize)+i]
 			if m == 1 {
 				// new generation
-				entry.program = NewRandomProgram(1)
+				entry.program = mutator.Instructions.RandomProgram(p.rng, 1)
 				entry.generation = 0
 			} else {
 				entry.program = append(Program{}, keepEntry.program...)
-				manipulation := rand.Intn(p.MaxManipulate)
+				manipulation := p.rng.Intn(p.MaxManipulate)
 				if manipulation == 0 {
 					manipulation++
 				}
-				entry.program = Mutate(entry.program, manipulation, p.entries[0:keepSize])
+				entry.program, entry.operators = mutator.mutateTimes(entry.program, manipulation, p.entries[0:keepSize])
+				entry.parentFitness = keepEntry.fitness
 				entry.generation = keepEntry.generation
 			}
 		}
 	}
-}
 
-func (e *Entry) exec(input string, maxRuntime int) error {
-	output := bytes.NewBuffer([]byte{})
-	i := NewInterpreter(output, bytes.NewReader([]byte(input)))
-	runtime, err := i.InterpretExtended(bytes.NewReader(e.program), false, maxRuntime)
-	e.err = err
-	if err == nil {
-		e.runtime = maxRuntime - runtime
-		e.output = output.Bytes()
-	} else {
-		e.runtime = 0
-		e.output = []byte{}
+	for _, o := range p.observers {
+		o(p.stats)
 	}
-	return err
 }
 
-func characterFitness(expected, actual byte) float64 {
-	diff := math.Abs(float64(expected)-float64(actual)) / 255.0
-	return 1.0 - diff
-}
-
-func (e *Entry) calculateFitness(expected []byte) {
+// evaluate runs the program for all cases, the fitness is summed and
+// success requires all cases to pass. The normalized program is run, so
+// unmatched brackets are removed instead of being skipped by the sloppy
+// interpreter, which is how the success code is reported
+func (e *Entry) evaluate(cases []TestCase, f Fitness, maxRuntime int, cache *Cache) {
+	program := Normalize(e.program)
 	fitness := 0.0
-	factor := 1.0
-	ok := true
-	for i, ch := range expected {
-		if i < len(e.output) {
-			ok = ok && ch == e.output[i]
-			if ok {
-				fitness += 1.0
-			} else {
-				fitness += (characterFitness(ch, e.output[i]) / factor)
-				factor *= 10
+	correctness := 0.0
+	success := true
+	runtime := 0
+	var output []byte
+	var firstErr error
+	for n, c := range cases {
+		err := e.exec(program, c.Input, maxRuntime, cache)
+		if n == 0 {
+			output = e.output
+		}
+		fitness += f.Fitness(e.program, e.output, e.runtime, err, c)
+		correctness -= float64(bitDistance(e.output, c.Expected))
+		if err != nil {
+			success = false
+			if firstErr == nil {
+				firstErr = err
 			}
-		} else {
-			ok = false
+			continue
 		}
+		success = success && bytes.Equal(e.output, c.Expected)
+		runtime += e.runtime
 	}
+	e.output = output
+	e.runtime = runtime
+	e.err = firstErr
+	e.fitness = fitness
+	e.correctness = correctness
+	e.success = success
+}
 
-	lenOutput := float64(len(e.output))
-	lenExpected := float64(len(expected))
-	if lenOutput > lenExpected {
-		fitness += (1.0 - (lenOutput / lenExpected))
+// exec runs the normalized program, so that the evaluation doesn't depend
+// on how the sloppy interpreter handles unmatched loops
+func (e *Entry) exec(program Program, input []byte, maxRuntime int, cache *Cache) error {
+	key := cacheKey{program: string(program), input: string(input), maxRuntime: maxRuntime}
+	item, ok := cache.get(key)
+	if !ok {
+		item = &cacheItem{key: key}
+		output := bytes.NewBuffer([]byte{})
+		i := NewInterpreter(output, bytes.NewReader(input))
+		runtime, err := i.InterpretExtended(bytes.NewReader(program), false, maxRuntime)
+		item.err = err
+		if err == nil {
+			item.runtime = maxRuntime - runtime
+			item.output = output.Bytes()
+		} else {
+			item.output = []byte{}
+		}
+		cache.put(item)
 	}
+	e.err = item.err
+	e.runtime = item.runtime
+	// the cached output is shared with other entries
+	e.output = append([]byte{}, item.output...)
+	return item.err
+}
 
-	if fitness > 0 {
-		factor *= 10
-		weight := 1.0 / float64(len(e.program)+1)
-		fitness += (weight / factor)
-	}
+// Program of the entry
+func (e *Entry) Program() Program {
+	return e.program
+}
 
-	e.fitness = fitness
+// Runtime of the entry summed over all test cases
+func (e *Entry) Runtime() int {
+	return e.runtime
 }
 
 func (e *Entry) String() string {
@@ -1,20 +1,22 @@
 package bf
 
-import "testing"
-
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+)
 
 func runPopulation(expected []byte, maxRuntime, maxIterations int, stopOnSuccess bool) *Population {
 	p := NewPopulation()
 	p.Expected = expected
 	p.MaxRuntime = maxRuntime
 	p.MaxManipulate = 1
-	for i := 1; i < maxIterations; i++ {
-		p.EvaluateAndMutate()
-		if stopOnSuccess && p.Fittest().success {
-			break
-		}
-	}
+	p.Seed(1)
+	p.Run(context.Background(), Criteria{
+		MaxGenerations: maxIterations - 1,
+		StopOnSuccess:  stopOnSuccess,
+	})
 	return p
 }
 
@@ -24,6 +26,9 @@ func TestHiPopulation(t *testing.T) {
 	if _, ok := hi.SuccessCode(); !ok {
 		t.Fail()
 	}
+	if len(hi.Front()) != 0 {
+		t.Error("front is only kept in multi-objective mode")
+	}
 }
 
 func TestHelloWorldPopulation(t *testing.T) {
@@ -33,6 +38,43 @@ func TestHelloWorldPopulation(t *testing.T) {
 	}
 }
 
+func TestUppercasePopulation(t *testing.T) {
+	rand.Seed(3)
+	p := NewPopulation()
+	p.Cases = []TestCase{
+		{Input: []byte("a"), Expected: []byte("A")},
+		{Input: []byte("q"), Expected: []byte("Q")},
+		{Input: []byte("z"), Expected: []byte("Z")},
+	}
+	if p.instructions() != InputInstructions {
+		t.Fatal("input instructions expected")
+	}
+	p.MaxRuntime = 200
+	p.MaxManipulate = 1
+	p.Run(context.Background(), Criteria{MaxGenerations: 500, StopOnSuccess: true})
+	if _, ok := p.SuccessCode(); !ok {
+		t.Fail()
+	}
+}
+
+func TestEvaluateCases(t *testing.T) {
+	e := Entry{program: Program(`,.`)}
+	e.evaluate([]TestCase{
+		{Input: []byte("a"), Expected: []byte("a")},
+		{Input: []byte("b"), Expected: []byte("c")},
+	}, DefaultFitness, 100, nil)
+	if e.success || e.err != nil || string(e.output) != "a" {
+		t.Fail()
+	}
+	e.evaluate([]TestCase{
+		{Input: []byte("a"), Expected: []byte("a")},
+		{Input: []byte("b"), Expected: []byte("b")},
+	}, DefaultFitness, 100, nil)
+	if !e.success || e.fitness < 2 || e.runtime != 6 {
+		t.Fail()
+	}
+}
+
 func TestHighByte(t *testing.T) {
 	p := runPopulation([]byte{byte(0xff)}, 256, 200, false)
 	_, ok := p.SuccessCode()
@@ -63,3 +105,28 @@ func TestCharacterFitness(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestMultiObjectivePopulation(t *testing.T) {
+	p := NewPopulation()
+	p.Expected = []byte("hi\n")
+	p.MaxRuntime = 200
+	p.MaxManipulate = 1
+	p.MultiObjective = true
+	p.Seed(1)
+	p.Run(context.Background(), Criteria{MaxGenerations: 150})
+	if _, ok := p.SuccessCode(); !ok {
+		t.Fatal("no success")
+	}
+	front := p.Front()
+	if len(front) == 0 {
+		t.Fatal("empty front")
+	}
+	for i, e := range front {
+		if !e.success {
+			t.Fail()
+		}
+		if i > 0 && (len(e.Program()) <= len(front[i-1].Program()) || e.Runtime() >= front[i-1].Runtime()) {
+			t.Errorf("dominated entry in front %d", i)
+		}
+	}
+}
@@ -8,11 +8,32 @@ import (
 // Instr represents a instruction
 type Instr byte
 
-const instr = `><+-.[]`
+// Instructions is the set of instructions used for generating random code
+type Instructions string
+
+// OutputInstructions generate programs that only write output
+const OutputInstructions Instructions = `><+-.[]`
+
+// InputInstructions generate programs that also read input
+const InputInstructions Instructions = `><+-.,[]`
+
+// Random returns a random instruction from the set
+func (s Instructions) Random(rng *rand.Rand) byte {
+	return s[rng.Intn(len(s))]
+}
+
+// RandomProgram makes a random program from the set
+func (s Instructions) RandomProgram(rng *rand.Rand, length int) Program {
+	p := NewProgram()
+	for i := 0; i < length; i++ {
+		p = append(p, s.Random(rng))
+	}
+	return p
+}
 
 // RandomInstr returns a random instruction
 func RandomInstr() byte {
-	return instr[rand.Intn(len(instr))]
+	return OutputInstructions[rand.Intn(len(OutputInstructions))]
 }
 
 // Program represents a program
@@ -110,49 +131,7 @@ func (p Program) Comment() Program {
 
 // Mutate a program randomly a number of times
 func Mutate(code Program, times int, sources []Entry) Program {
-	for i := 0; i < times; i++ {
-		code = mutate(code, sources)
-	}
+	m := newMutator(rand.New(rand.NewSource(rand.Int63())), OutputInstructions, nil)
+	code, _ = m.mutateTimes(code, times, sources)
 	return code
 }
-
-func mutate(code Program, sources []Entry) Program {
-	if len(code) == 0 {
-		return NewRandomProgram(1)
-	}
-
-	pos := rand.Intn(len(code))
-	length := rand.Intn(len(code) - pos)
-
-	switch rand.Intn(8) {
-	case 0:
-		return insertAt(code, pos, NewRandomProgram(1))
-	case 1:
-		return removeAt(code, pos, 1)
-	case 2:
-		p := NewProgramClone(code)
-		p[pos] = RandomInstr()
-		return p
-	case 3:
-		apos := rand.Intn(len(code))
-		len := rand.Intn(len(code) - apos)
-		return insertAt(code, pos, code[apos:apos+len])
-	case 4:
-		// cross breed partials
-		pick := NewProgramClone(sources[rand.Intn(len(sources))].program)
-		pickPos := rand.Intn(len(pick))
-		pickLength := rand.Intn(len(pick) - pickPos)
-		without := removeAt(code, pos, length)
-		return insertAt(without, pos, pick[pickPos:pickPos+pickLength])
-	case 5:
-		return removeAt(code, pos, length)
-	case 6:
-		// insert some loops for variety
-		c := rand.Intn(len(compounds))
-		return insertAt(code, pos, compounds[c])
-	case 7:
-		without := removeAt(code, pos, length)
-		return insertAt(without, pos, code[pos:pos+length].Comment())
-	}
-	panic("unreachable")
-}
@@ -0,0 +1,26 @@
+package bf
+
+// source is a random source with a state that can be saved and restored,
+// the numbers are generated using splitmix64
+type source struct {
+	state uint64
+}
+
+// Seed initializes the state
+func (s *source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns the next random number
+func (s *source) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns the next random non-negative number
+func (s *source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
@@ -0,0 +1,93 @@
+package bf
+
+import (
+	"context"
+	"time"
+)
+
+// Criteria determines when to stop the evolution, zero values are ignored
+type Criteria struct {
+	// MaxGenerations is the total number of generations of the population
+	MaxGenerations int
+	MaxDuration    time.Duration
+	StopOnSuccess  bool
+	// MaxStagnation is the number of generations without improvement of the best fitness
+	MaxStagnation int
+	// TargetLength stops when a successful program is at most this length
+	TargetLength int
+}
+
+// StopReason describes why the evolution stopped
+type StopReason string
+
+// Reasons to stop the evolution
+const (
+	StopCanceled       StopReason = "canceled"
+	StopMaxGenerations StopReason = "max generations"
+	StopMaxDuration    StopReason = "max duration"
+	StopSuccess        StopReason = "success"
+	StopStagnation     StopReason = "stagnation"
+	StopTargetLength   StopReason = "target length"
+)
+
+// Result summarizes a run
+type Result struct {
+	Reason      StopReason
+	Generations int
+	Duration    time.Duration
+	Success     bool
+	Code        Program
+	Fitness     float64
+}
+
+// Run evolves the population until one of the criteria is met or the
+// context is done
+func (p *Population) Run(ctx context.Context, c Criteria) Result {
+	start := time.Now()
+	generations := 0
+	best := 0.0
+	stagnation := 0
+	result := func(reason StopReason) Result {
+		code, success := p.SuccessCode()
+		return Result{
+			Reason:      reason,
+			Generations: generations,
+			Duration:    time.Since(start),
+			Success:     success,
+			Code:        code,
+			Fitness:     p.Fittest().fitness,
+		}
+	}
+	for {
+		if ctx.Err() != nil {
+			return result(StopCanceled)
+		}
+		if c.MaxGenerations > 0 && p.generation >= c.MaxGenerations {
+			return result(StopMaxGenerations)
+		}
+		if c.MaxDuration > 0 && time.Since(start) >= c.MaxDuration {
+			return result(StopMaxDuration)
+		}
+
+		p.EvaluateAndMutate()
+		generations++
+
+		fitness := p.Fittest().fitness
+		if generations == 1 || fitness > best {
+			best = fitness
+			stagnation = 0
+		} else {
+			stagnation++
+		}
+		code, success := p.SuccessCode()
+		if success && c.StopOnSuccess {
+			return result(StopSuccess)
+		}
+		if success && c.TargetLength > 0 && len(code) <= c.TargetLength {
+			return result(StopTargetLength)
+		}
+		if c.MaxStagnation > 0 && stagnation >= c.MaxStagnation {
+			return result(StopStagnation)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package bf
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunCriteria(t *testing.T) {
+	p := NewPopulation()
+	p.Expected = []byte("hi")
+	p.MaxRuntime = 200
+	p.Seed(1)
+	r := p.Run(context.Background(), Criteria{MaxGenerations: 3})
+	if r.Reason != StopMaxGenerations || r.Generations != 3 || p.Generation() != 3 {
+		t.Errorf("%+v", r)
+	}
+	// generations are counted for the population
+	r = p.Run(context.Background(), Criteria{MaxGenerations: 5})
+	if r.Generations != 2 || p.Generation() != 5 {
+		t.Errorf("%+v", r)
+	}
+	r = p.Run(context.Background(), Criteria{MaxDuration: time.Nanosecond})
+	if r.Reason != StopMaxDuration {
+		t.Errorf("%+v", r)
+	}
+	r = p.Run(context.Background(), Criteria{StopOnSuccess: true, MaxGenerations: 500})
+	if r.Reason != StopSuccess || !r.Success || len(r.Code) == 0 {
+		t.Errorf("%+v", r)
+	}
+	r = p.Run(context.Background(), Criteria{MaxStagnation: 1})
+	if r.Reason != StopStagnation {
+		t.Errorf("%+v", r)
+	}
+	r = p.Run(context.Background(), Criteria{TargetLength: 1000})
+	if r.Reason != StopTargetLength || r.Generations != 1 {
+		t.Errorf("%+v", r)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = p.Run(ctx, Criteria{})
+	if r.Reason != StopCanceled || r.Generations != 0 {
+		t.Errorf("%+v", r)
+	}
+}
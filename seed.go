@@ -0,0 +1,32 @@
+package bf
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// ReadProgram reads a program and strips everything that isn't an
+// instruction, the loops are normalized
+func ReadProgram(r io.Reader) (Program, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Normalize(commands(data)), nil
+}
+
+// SeedPrograms replaces the entries with copies of the programs, the
+// first copy of every program is exact and the other copies are mutated
+func (p *Population) SeedPrograms(programs []Program) {
+	if len(programs) == 0 {
+		return
+	}
+	mutator := newMutator(p.rng, p.instructions(), p.OperatorWeights)
+	for i := range p.entries {
+		program := NewProgramClone(programs[i%len(programs)])
+		if i >= len(programs) {
+			program, _ = mutator.mutateTimes(program, 1+p.rng.Intn(p.MaxManipulate), nil)
+		}
+		p.entries[i] = Entry{program: program}
+	}
+}
@@ -0,0 +1,44 @@
+package bf
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadProgram(t *testing.T) {
+	program, err := ReadProgram(strings.NewReader("+[-] clear\n]>. print"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(program) != "+[-]>." {
+		t.Fatalf("unexpected program %q", program)
+	}
+}
+
+func TestSeedPrograms(t *testing.T) {
+	file, err := os.Open("examples/hello.bf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	hello, err := ReadProgram(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPopulation()
+	p.Seed(1)
+	p.Expected = []byte("Hello World!!\n")
+	p.MaxRuntime = 1000
+	p.SeedPrograms([]Program{hello})
+	if !bytes.Equal(p.entries[0].program, hello) || bytes.Equal(p.entries[1].program, hello) {
+		t.Fatal("expected exact and mutated copies")
+	}
+	result := p.Run(context.Background(), Criteria{MaxGenerations: 200, StopOnSuccess: true})
+	if !result.Success {
+		t.Fatalf("no variation found after %d generations", result.Generations)
+	}
+}
@@ -0,0 +1,157 @@
+package bf
+
+import "bytes"
+
+// cellState is the knowledge about the current cell during simplification
+type cellState struct {
+	zero  bool // current cell is known to be zero
+	fresh bool // no cell has been modified yet
+}
+
+var inverse = map[byte]byte{'+': '-', '-': '+', '>': '<', '<': '>'}
+
+// Simplify removes code that doesn't change the output of a program:
+// comments, inverse pairs like `+-` and `<>`, loops on cells that are known to
+// be zero (like `[-][...]`), empty loops and everything after the last output.
+// Changes to a cell before it is cleared are also removed. Simplification
+// preserves the output of programs that terminate.
+func Simplify(program Program) Program {
+	code := Normalize(commands(program))
+	for {
+		simplified := simplify(code)
+		if bytes.Equal(simplified, code) {
+			return simplified
+		}
+		code = simplified
+	}
+}
+
+// commands filters the instructions from the program
+func commands(program Program) Program {
+	code := NewProgram()
+	for _, b := range program {
+		if bytes.IndexByte([]byte(InputInstructions), b) >= 0 {
+			code = append(code, b)
+		}
+	}
+	return code
+}
+
+// matchingLoop returns the position of the bracket that closes the loop at pos
+func matchingLoop(code Program, pos int) int {
+	level := 0
+	for i := pos; i < len(code); i++ {
+		if code[i] == '[' {
+			level++
+		} else if code[i] == ']' {
+			level--
+			if level == 0 {
+				return i
+			}
+		}
+	}
+	return len(code) - 1
+}
+
+// simplify does a single pass over balanced code
+func simplify(code Program) Program {
+	out := NewProgram()
+	// state before each instruction of out
+	states := []cellState{}
+	state := cellState{zero: true, fresh: true}
+	loops := []int{}
+	push := func(b byte) {
+		states = append(states, state)
+		out = append(out, b)
+	}
+	truncate := func(n int) {
+		state = states[n]
+		states = states[:n]
+		out = out[:n]
+	}
+	for i := 0; i < len(code); i++ {
+		b := code[i]
+		switch b {
+		case '+', '-', '<', '>':
+			if n := len(out); n > 0 && out[n-1] == inverse[b] {
+				truncate(n - 1)
+				continue
+			}
+			push(b)
+			if b == '+' || b == '-' {
+				state = cellState{}
+			} else {
+				state.zero = state.fresh
+			}
+		case '.':
+			push(b)
+		case ',':
+			push(b)
+			state = cellState{}
+		case '[':
+			if state.zero {
+				// dead loop
+				i = matchingLoop(code, i)
+				continue
+			}
+			loops = append(loops, len(out))
+			push(b)
+			state = cellState{}
+		case ']':
+			open := loops[len(loops)-1]
+			loops = loops[:len(loops)-1]
+			body := out[open+1:]
+			if len(body) == 0 {
+				// a terminating empty loop means the cell was zero
+				truncate(open)
+				state.zero = true
+				continue
+			}
+			if len(body) == 1 && (body[0] == '-' || body[0] == '+') {
+				// changes before clearing a cell are dead
+				clear := body[0]
+				start := open
+				for start > 0 && (out[start-1] == '+' || out[start-1] == '-') {
+					start--
+				}
+				truncate(start)
+				if state.zero {
+					continue
+				}
+				push('[')
+				state = cellState{}
+				push(clear)
+			}
+			push(b)
+			state = cellState{zero: true}
+		}
+	}
+	return trimAfterOutput(out)
+}
+
+// trimAfterOutput removes the instructions after the last output
+func trimAfterOutput(code Program) Program {
+	last := bytes.LastIndexByte(code, '.')
+	if last < 0 {
+		return code[:0]
+	}
+	level := 0
+	for _, b := range code[:last] {
+		if b == '[' {
+			level++
+		} else if b == ']' {
+			level--
+		}
+	}
+	for i := last + 1; i < len(code); i++ {
+		if level == 0 {
+			return code[:i]
+		}
+		if code[i] == '[' {
+			level++
+		} else if code[i] == ']' {
+			level--
+		}
+	}
+	return code
+}
@@ -0,0 +1,73 @@
+package bf
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	cases := map[string]string{
+		`++-.`:            `+.`,
+		`><+.`:            `+.`,
+		`+>+-<-.`:         `.`,
+		`+[-][>+<-]+.`:    `+.`,
+		`[>+<-]+.`:        `+.`,
+		`+.[-][.]+.`:      `+.[-]+.`,
+		`>+[]<.`:          `>+<.`,
+		`++[-]+.`:         `+.`,
+		`,++[-]+.`:        `,[-]+.`,
+		`+.+`:             `+.`,
+		`+[.-]>+`:         `+[.-]`,
+		`comment +. done`: `+.`,
+		`+]]-.[`:          `.`,
+		`+`:               ``,
+	}
+	for program, expected := range cases {
+		if simplified := Simplify(Program(program)); string(simplified) != expected {
+			t.Errorf("%s = %s != %s", program, simplified, expected)
+		}
+	}
+}
+
+func run(t *testing.T, program Program) string {
+	out := &strings.Builder{}
+	i := NewInterpreter(out, nil)
+	if err := i.Interpret(bytes.NewReader(program)); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestSimplifyExamples(t *testing.T) {
+	for _, name := range []string{"hello", "smiley", "christmas"} {
+		program, err := ioutil.ReadFile("examples/" + name + ".bf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		simplified := Simplify(program)
+		if run(t, simplified) != run(t, program) || len(simplified) >= len(program) {
+			t.Errorf("%s simplified incorrectly", name)
+		}
+	}
+}
+
+func TestSimplifySuccessCode(t *testing.T) {
+	p := NewPopulation()
+	p.Expected = []byte("hi\n")
+	p.MaxRuntime = 200
+	p.MaxManipulate = 3
+	p.Seed(1)
+	p.Run(context.Background(), Criteria{MaxGenerations: 200, StopOnSuccess: true})
+	code, ok := p.SuccessCode()
+	if !ok {
+		t.Fatal("no success")
+	}
+	p.Simplify = true
+	simplified, _ := p.SuccessCode()
+	if len(simplified) > len(code) || !p.Verify(simplified) {
+		t.Errorf("%s not simplified from %s", simplified, code)
+	}
+}
@@ -0,0 +1,91 @@
+package bf
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Stats summarizes the evaluation of a generation
+type Stats struct {
+	Generation           int     `json:"generation"`
+	Best                 float64 `json:"best"`
+	Mean                 float64 `json:"mean"`
+	Median               float64 `json:"median"`
+	Worst                float64 `json:"worst"`
+	Diversity            float64 `json:"diversity"`
+	Distance             float64 `json:"distance"`
+	MeanLength           float64 `json:"mean_length"`
+	Successes            int     `json:"successes"`
+	EvaluationsPerSecond float64 `json:"evaluations_per_second"`
+	CacheHitRate         float64 `json:"cache_hit_rate"`
+}
+
+// StatsHeader names the columns returned by Record
+var StatsHeader = []string{
+	"generation", "best", "mean", "median", "worst",
+	"diversity", "distance", "mean_length", "successes", "evaluations_per_second",
+	"cache_hit_rate",
+}
+
+// Record formats the stats as columns for csv output
+func (s Stats) Record() []string {
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return []string{
+		strconv.Itoa(s.Generation), f(s.Best), f(s.Mean), f(s.Median), f(s.Worst),
+		f(s.Diversity), f(s.Distance), f(s.MeanLength), strconv.Itoa(s.Successes), f(s.EvaluationsPerSecond),
+		f(s.CacheHitRate),
+	}
+}
+
+// Observer is called after every generation
+type Observer func(stats Stats)
+
+// Observe registers an observer that is called after every generation
+func (p *Population) Observe(o Observer) {
+	p.observers = append(p.observers, o)
+}
+
+// Stats returns the stats of the last evaluated generation
+func (p *Population) Stats() Stats {
+	return p.stats
+}
+
+// calculateStats summarizes the evaluated entries, diversity is the ratio of
+// unique programs in the population and distance is the estimated mean edit
+// distance between the kept programs
+func (p *Population) calculateStats(evaluations int, elapsed time.Duration) Stats {
+	n := len(p.entries)
+	fitness := make([]float64, n)
+	unique := map[string]bool{}
+	stats := Stats{Generation: p.generation}
+	sum := 0.0
+	length := 0
+	for i, e := range p.entries {
+		fitness[i] = e.fitness
+		sum += e.fitness
+		length += len(e.program)
+		unique[string(e.program)] = true
+		if e.success {
+			stats.Successes++
+		}
+	}
+	sort.Float64s(fitness)
+	stats.Best = fitness[n-1]
+	stats.Worst = fitness[0]
+	stats.Mean = sum / float64(n)
+	if n%2 == 0 {
+		stats.Median = (fitness[n/2-1] + fitness[n/2]) / 2
+	} else {
+		stats.Median = fitness[n/2]
+	}
+	stats.Diversity = float64(len(unique)) / float64(n)
+	stats.Distance = meanDistance(p.entries[:keepSize])
+	stats.MeanLength = float64(length) / float64(n)
+	if elapsed > 0 {
+		stats.EvaluationsPerSecond = float64(evaluations) / elapsed.Seconds()
+	}
+	return stats
+}
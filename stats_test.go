@@ -0,0 +1,28 @@
+package bf
+
+import "testing"
+
+func TestObserveStats(t *testing.T) {
+	p := NewPopulation()
+	p.Expected = []byte("hi")
+	p.MaxRuntime = 200
+	var observed []Stats
+	p.Observe(func(stats Stats) {
+		observed = append(observed, stats)
+	})
+	p.EvaluateAndMutate()
+	p.EvaluateAndMutate()
+	if len(observed) != 2 || observed[1].Generation != 2 || p.Stats() != observed[1] {
+		t.Fatal("observer not called for every generation")
+	}
+	s := observed[1]
+	if !(s.Worst <= s.Median && s.Median <= s.Best && s.Worst <= s.Mean && s.Mean <= s.Best) {
+		t.Errorf("%+v", s)
+	}
+	if s.Diversity <= 0 || s.Diversity > 1 || s.MeanLength <= 0 {
+		t.Errorf("%+v", s)
+	}
+	if len(s.Record()) != len(StatsHeader) {
+		t.Fail()
+	}
+}